@@ -1,84 +1,219 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
-	cudosapp "github.com/CudoVentures/cudos-node/app"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/failover"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/grpcclient"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/grpcserver"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/handlers"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/health"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/metrics"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/distribution"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/tasks"
-	"github.com/cosmos/cosmos-sdk/simapp/params"
-	"github.com/cosmos/cosmos-sdk/std"
-	"github.com/cosmos/cosmos-sdk/types/module"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/upstream"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
-	"github.com/forbole/juno/v2/node/remote"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/go-co-op/gocron"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
 )
 
+// Servers are given this long to finish the requests in flight on shutdown
+const shutdownTimeout = 20 * time.Second
+
 func main() {
-	cfg, err := config.NewConfig("config.yaml")
+	configPath := flag.String("config", getDefaultConfigPath(), "path of the config file")
+	resetCheckpoint := flag.Bool("reset-norm-time-checkpoint", false, "recompute the APR norm time from the genesis instead of the stored checkpoint")
+	flag.Parse()
+
+	// Errors are returned instead of exiting right away, so the deferred calls of run close the storage
+	if err := run(*configPath, *resetCheckpoint); err != nil {
+		log.Fatal().Err(err).Send()
+	}
+}
+
+func run(configPath string, resetCheckpoint bool) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.NewConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("creating config failed: %s", err)
+	}
+
+	// Connections to the RPC, REST and Ethereum nodes are pooled in one transport
+	upstreamTransport, err := upstream.NewTransport(cfg)
 	if err != nil {
-		log.Fatal().Err(fmt.Errorf("creating config failed: %s", err)).Send()
-		return
+		return fmt.Errorf("error while creating upstream http transport: %s", err)
 	}
 
-	encodingConfig := makeEncodingConfig([]module.BasicManager{
-		cudosapp.ModuleBasics,
-	})()
+	endpoints := cfg.Cudos.Endpoints
+
+	cudosClient := upstream.NewClient(cfg, upstreamTransport, cfg.Upstream.CudosHeaders)
 
-	nodeClient, err := remote.NewNode(&cfg.Cudos.NodeDetails, encodingConfig.Marshaler)
+	rpcPool, err := failover.NewRPCPool(endpoints.RPC, cudosClient, endpoints.MaxHeightLag)
 	if err != nil {
-		log.Fatal().Err(fmt.Errorf("error while creating node client: %s", err)).Send()
-		return
+		return fmt.Errorf("error while creating node client: %s", err)
 	}
 
-	source, err := remote.NewSource(cfg.Cudos.NodeDetails.GRPC)
+	grpcDialOptions, err := upstream.NewGRPCDialOptions(cfg, cfg.Cudos.NodeDetails.GRPC != nil && cfg.Cudos.NodeDetails.GRPC.Insecure)
 	if err != nil {
-		log.Fatal().Err(fmt.Errorf("error while creating remote source: %s", err)).Send()
-		return
+		return fmt.Errorf("error while creating grpc dial options: %s", err)
 	}
 
-	stakingClient := stakingtypes.NewQueryClient(source.GrpcConn)
-	bankingRestClient := bank.NewRestClient(cfg.Cudos.REST.Address)
-	distributionRestClient := distribution.NewRestClient(cfg.Cudos.REST.Address)
+	grpcConn, err := failover.NewGRPCConn(endpoints.GRPC, grpcDialOptions, endpoints.MaxHeightLag, endpoints.AttemptTimeout)
+	if err != nil {
+		return fmt.Errorf("error while creating grpc connection: %s", err)
+	}
 
-	keyValueStorage := storage.NewStorage()
+	stakingClient := stakingtypes.NewQueryClient(grpcConn)
+	bankingQueryClient, distributionQueryClient, restPool := newQueryClients(cfg, grpcConn, cudosClient)
 
-	log.Info().Msg("Executing tasks")
+	// Heights are taken from the guard so every endpoint the queries fail over to has the state at them
+	pools := []*failover.Pool{rpcPool, grpcConn.Pool()}
+	if restPool != nil {
+		pools = append(pools, restPool)
+	}
+	nodeClient := failover.NewGuard(pools...)
 
-	if err := tasks.ExecuteTasks(cfg, nodeClient, stakingClient, bankingRestClient, distributionRestClient, keyValueStorage); err != nil {
-		log.Fatal().Err(fmt.Errorf("error while executing tasks: %s", err)).Send()
-		return
+	var ethClient *ethclient.Client
+	if cfg.Eth.Enabled {
+		rpcClient, err := rpc.DialHTTPWithClient(cfg.Eth.EthNode, upstream.NewClient(cfg, upstreamTransport, cfg.Upstream.EthHeaders))
+		if err != nil {
+			return fmt.Errorf("error while dialing eth node: %s", err)
+		}
+		ethClient = ethclient.NewClient(rpcClient)
 	}
 
+	keyValueStorage, err := storage.New(cfg.Storage.Backend, cfg.Storage.Path, cfg.Storage.HistoryRetention)
+	if err != nil {
+		return fmt.Errorf("error while creating storage: %s", err)
+	}
+	// Deferred first so it runs last, once the servers and the scheduler stopped
+	defer func() {
+		if err := keyValueStorage.Close(); err != nil {
+			log.Error().Err(fmt.Errorf("error while closing storage: %s", err)).Send()
+		}
+	}()
+
+	if resetCheckpoint {
+		if err := tasks.ResetNormTimeCheckpoint(cfg, keyValueStorage); err != nil {
+			return fmt.Errorf("error while resetting norm time checkpoint: %s", err)
+		}
+		log.Info().Msg("Norm time checkpoint reset, the APR is recomputed from the genesis")
+	}
+
+	if err := metrics.RegisterStorageCollector(cfg, keyValueStorage); err != nil {
+		return fmt.Errorf("error while registering metrics: %s", err)
+	}
+
+	bankingClient := metrics.InstrumentBankClient(bankingQueryClient)
+
+	runner, err := tasks.NewRunner(cfg, nodeClient, metrics.InstrumentStakingClient(stakingClient), bankingClient,
+		metrics.InstrumentDistributionClient(distributionQueryClient), metrics.InstrumentEthClient(ethClient), keyValueStorage)
+	if err != nil {
+		return fmt.Errorf("error while creating task runner: %s", err)
+	}
+
+	heightCalculator, err := tasks.NewHeightCalculator(cfg, bankingClient)
+	if err != nil {
+		return fmt.Errorf("error while creating height calculator: %s", err)
+	}
+
+	// Tasks are executed in the background so the last persisted values are served while they are being recalculated
+	go func() {
+		log.Info().Msg("Executing tasks")
+
+		// Failed tasks are retried on their next scheduled run, so they don't stop the service
+		if err := runner.ExecuteTasks(); err != nil {
+			log.Error().Err(fmt.Errorf("error while executing tasks: %s", err)).Send()
+		}
+	}()
+
 	log.Info().Msg("Registering tasks")
 	scheduler := gocron.NewScheduler(time.UTC)
 
-	if err := tasks.RegisterTasks(scheduler, cfg, nodeClient, stakingClient, bankingRestClient, distributionRestClient, keyValueStorage); err != nil {
-		log.Fatal().Err(fmt.Errorf("error while registering tasks: %s", err)).Send()
-		return
+	if err := runner.RegisterTasks(scheduler); err != nil {
+		return fmt.Errorf("error while registering tasks: %s", err)
+	}
+
+	// Endpoints are checked whenever a task gets the latest height as well, this takes back recovered ones in between
+	if interval := endpoints.HealthCheckInterval; interval > 0 {
+		_, err := scheduler.Every(interval).WaitForSchedule().Do(func() {
+			if _, err := nodeClient.LatestHeight(); err != nil {
+				log.Warn().Err(err).Send()
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("error while registering endpoint health check: %s", err)
+		}
 	}
 
 	scheduler.StartAsync()
+	defer scheduler.Stop()
 
 	log.Info().Msg("Registering http handlers")
 
 	r := mux.NewRouter()
 	r.HandleFunc("/cosmos/mint/v1beta1/annual_provisions", handlers.GetAnnualProvisionsHandler(cfg, keyValueStorage))
-	r.HandleFunc("/cosmos/mint/v1beta1/inflation", handlers.GetInflationHandler(cfg, keyValueStorage))
+	r.HandleFunc("/cosmos/mint/v1beta1/inflation", handlers.GetInflationHandler(cfg, keyValueStorage, heightCalculator))
 	r.HandleFunc("/cosmos/mint/v1beta1/params", handlers.GetParamsHandler(cfg))
-	r.HandleFunc("/cosmos/bank/v1beta1/supply", handlers.GetSupplyHandler(cfg, keyValueStorage))
+	r.HandleFunc("/cosmos/bank/v1beta1/supply", handlers.GetSupplyHandler(cfg, keyValueStorage, heightCalculator))
+	r.HandleFunc("/cosmos/bank/v1beta1/supply/by_denom", handlers.GetSupplyOfHandler(cfg, keyValueStorage, heightCalculator))
+	r.HandleFunc("/cosmos/bank/v1beta1/supply/{denom:.+}", handlers.GetSupplyOfHandler(cfg, keyValueStorage, heightCalculator))
 	r.HandleFunc("/circulating-supply", handlers.GetCircSupplyTextHandler(cfg, keyValueStorage))
 	r.HandleFunc("/json/circulating-supply", handlers.GetCircSupplyJSONHandler(cfg, keyValueStorage))
 	r.HandleFunc("/total-supply", handlers.GetCudosNetworkTotalSupply(cfg, keyValueStorage))
+	r.HandleFunc("/excluded-accounts", handlers.GetExcludedAccountsHandler(cfg, keyValueStorage))
 	r.HandleFunc("/stats", handlers.GetStatsHandler(cfg, keyValueStorage))
+	r.HandleFunc("/history/{metric}", handlers.GetHistoryHandler(cfg, keyValueStorage))
+	r.HandleFunc("/tasks", handlers.GetTasksHandler(runner))
+	r.Handle("/metrics", metrics.Handler())
+	r.HandleFunc("/healthz", handlers.GetHealthzHandler())
+	r.HandleFunc("/readyz", handlers.GetReadyzHandler(health.NewChecker(cfg, keyValueStorage, nodeClient)))
+	r.Use(metrics.HTTPMiddleware)
+	r.NotFoundHandler = handlers.GetNotFoundHandler()
+
+	// A failing server ends the service like a signal does, after the other one is shut down
+	serveErrs := make(chan error, 2)
+
+	// The gRPC server is optional and disabled by leaving its port unset
+	var grpcServer *grpc.Server
+	if cfg.GRPCPort != 0 {
+		grpcServer, err = grpcserver.NewServer(cfg, keyValueStorage)
+		if err != nil {
+			return fmt.Errorf("error while creating grpc server: %s", err)
+		}
+
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
+		if err != nil {
+			return fmt.Errorf("error while listening on grpc port: %s", err)
+		}
+
+		go func() {
+			log.Info().Msg(fmt.Sprintf("Listening for grpc on port: %d", cfg.GRPCPort))
+
+			if err := grpcServer.Serve(listener); err != nil {
+				serveErrs <- fmt.Errorf("error while serving grpc: %s", err)
+			}
+		}()
+	}
 
 	log.Info().Msg(fmt.Sprintf("Listening on port: %d", cfg.Port))
 	srv := &http.Server{
@@ -89,29 +224,78 @@ func main() {
 		ReadTimeout:  15 * time.Second,
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Fatal().Err(fmt.Errorf("error while listening: %s", err))
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErrs <- fmt.Errorf("error while listening: %s", err)
+		}
+	}()
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		log.Info().Msg("Shutting down")
+	case serveErr = <-serveErrs:
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(fmt.Errorf("error while shutting down http server: %s", err)).Send()
+	}
+
+	if grpcServer != nil {
+		stopGRPCServer(shutdownCtx, grpcServer)
 	}
+
+	return serveErr
 }
 
-func makeEncodingConfig(managers []module.BasicManager) func() params.EncodingConfig {
-	return func() params.EncodingConfig {
-		encodingConfig := params.MakeTestEncodingConfig()
-		std.RegisterLegacyAminoCodec(encodingConfig.Amino)
-		std.RegisterInterfaces(encodingConfig.InterfaceRegistry)
-		manager := mergeBasicManagers(managers)
-		manager.RegisterLegacyAminoCodec(encodingConfig.Amino)
-		manager.RegisterInterfaces(encodingConfig.InterfaceRegistry)
-		return encodingConfig
+// stopGRPCServer waits for the calls in flight until ctx is done and cancels the remaining ones then
+func stopGRPCServer(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
 	}
 }
 
-func mergeBasicManagers(managers []module.BasicManager) module.BasicManager {
-	var union = module.BasicManager{}
-	for _, manager := range managers {
-		for k, v := range manager {
-			union[k] = v
+// Over REST the legacy endpoints were removed in newer Cosmos SDK versions, the gateway endpoints replace them.
+// The pool of the REST endpoints is only returned when they are used.
+func newQueryClients(cfg config.Config, grpcConn *failover.Conn, httpClient *http.Client) (bank.Client, distribution.Client, *failover.Pool) {
+	if cfg.Cudos.QueryTransport == config.QueryTransportGRPC {
+		return grpcclient.NewBankClient(grpcConn, cfg.Cudos.MaxPages), grpcclient.NewDistributionClient(grpcConn), nil
+	}
+
+	addresses := cfg.Cudos.Endpoints.REST
+	bankClients := make([]bank.Client, len(addresses))
+	distributionClients := make([]distribution.Client, len(addresses))
+	latestBlockPath := rest.LegacyLatestBlockPath
+
+	for i, address := range addresses {
+		if cfg.Cudos.REST.API == config.RESTAPIGateway {
+			bankClients[i], distributionClients[i] = bank.NewGatewayClient(httpClient, address, cfg.Cudos.MaxPages), distribution.NewGatewayClient(httpClient, address)
+			latestBlockPath = rest.GatewayLatestBlockPath
+		} else {
+			bankClients[i], distributionClients[i] = bank.NewRestClient(httpClient, address, cfg.Cudos.MaxPages), distribution.NewRestClient(httpClient, address)
 		}
 	}
-	return union
+
+	pool := failover.NewRESTPool(httpClient, addresses, latestBlockPath, cfg.Cudos.Endpoints.MaxHeightLag, cfg.Cudos.Endpoints.AttemptTimeout)
+
+	return failover.NewBankClient(pool, bankClients), failover.NewDistributionClient(pool, distributionClients), pool
+}
+
+// The config file is looked up in the working directory unless CUDOS_STATS_CONFIG is set, the flag overrides both
+func getDefaultConfigPath() string {
+	if path, ok := os.LookupEnv(config.EnvPrefix + "CONFIG"); ok {
+		return path
+	}
+	return "config.yaml"
 }
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"time"
 
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/forbole/juno/v2/node/remote"
 	"gopkg.in/yaml.v2"
 )
@@ -22,11 +27,98 @@ func NewConfig(configPath string) (Config, error) {
 		return config, err
 	}
 
+	// Environment variables take precedence over the file
+	if err := applyEnvOverrides(&config); err != nil {
+		return config, err
+	}
+
+	nonGravityAccounts := 0
+	for _, account := range config.Calculation.ExcludedAccounts {
+		if !isValidExcludedAccountCategory(account.Category) {
+			return config, fmt.Errorf("invalid category %s of excluded account %s", account.Category, account.Address)
+		}
+		if account.Category != ExcludedAccountCategoryGravity {
+			nonGravityAccounts++
+		}
+	}
+
+	if config.Calculation.FixedExcludedAmount != "" {
+		if amount, ok := sdk.NewIntFromString(config.Calculation.FixedExcludedAmount); !ok || amount.IsNegative() {
+			return config, fmt.Errorf("invalid calculation.fixed_excluded_amount %s", config.Calculation.FixedExcludedAmount)
+		}
+	}
+
+	// Without the treasury, vesting and foundation accounts about 1.94B CUDOS would be counted as circulating
+	if nonGravityAccounts == 0 && config.Calculation.FixedExcludedAmount == "" {
+		return config, errors.New("calculation.excluded_accounts has no treasury, vesting or foundation account and calculation.fixed_excluded_amount is not set")
+	}
+
+	switch config.Cudos.QueryTransport {
+	case "", QueryTransportREST, QueryTransportGRPC:
+	default:
+		return config, fmt.Errorf("invalid cudos query transport %s", config.Cudos.QueryTransport)
+	}
+
+	switch config.Cudos.REST.API {
+	case "", RESTAPILegacy, RESTAPIGateway:
+	default:
+		return config, fmt.Errorf("invalid cudos rest api %s", config.Cudos.REST.API)
+	}
+
+	// The node is dialed through the upstream http client, websocket and ipc endpoints would bypass it
+	if config.Eth.Enabled {
+		nodeURL, err := url.Parse(config.Eth.EthNode)
+		if err != nil || (nodeURL.Scheme != "http" && nodeURL.Scheme != "https") || nodeURL.Host == "" {
+			return config, fmt.Errorf("eth.node %s has to be an http or https url", config.Eth.EthNode)
+		}
+	}
+
+	setDefaultEndpoints(&config)
+
+	if len(config.Cudos.Endpoints.RPC) == 0 {
+		return config, errors.New("no cudos rpc endpoint is set")
+	}
+
+	if len(config.Cudos.Endpoints.GRPC) == 0 {
+		return config, errors.New("no cudos grpc endpoint is set")
+	}
+
+	if config.Cudos.QueryTransport != QueryTransportGRPC && len(config.Cudos.Endpoints.REST) == 0 {
+		return config, errors.New("no cudos rest endpoint is set")
+	}
+
 	return config, nil
 }
 
+// The single node and rest addresses of older configs are used as endpoint lists of one
+func setDefaultEndpoints(config *Config) {
+	endpoints := &config.Cudos.Endpoints
+
+	if len(endpoints.RPC) == 0 && config.Cudos.NodeDetails.RPC != nil && config.Cudos.NodeDetails.RPC.Address != "" {
+		endpoints.RPC = []string{config.Cudos.NodeDetails.RPC.Address}
+	}
+
+	if len(endpoints.GRPC) == 0 && config.Cudos.NodeDetails.GRPC != nil && config.Cudos.NodeDetails.GRPC.Address != "" {
+		endpoints.GRPC = []string{config.Cudos.NodeDetails.GRPC.Address}
+	}
+
+	if len(endpoints.REST) == 0 && config.Cudos.REST.Address != "" {
+		endpoints.REST = []string{config.Cudos.REST.Address}
+	}
+}
+
+func isValidExcludedAccountCategory(category string) bool {
+	switch category {
+	case ExcludedAccountCategoryTreasury, ExcludedAccountCategoryVesting, ExcludedAccountCategoryFoundation, ExcludedAccountCategoryGravity:
+		return true
+	default:
+		return false
+	}
+}
+
 type Config struct {
 	Port             int `yaml:"port"`
+	GRPCPort         int `yaml:"grpc_port"`
 	InflationGenesis struct {
 		InitialHeight         int64  `yaml:"initial_height"`
 		NormTimePassed        string `yaml:"norm_time_passed"`
@@ -44,27 +136,114 @@ type Config struct {
 	} `yaml:"apr_genesis"`
 	Cudos struct {
 		NodeDetails remote.Details `yaml:"node"`
-		REST        struct {
+		Endpoints   struct {
+			RPC                 []string      `yaml:"rpc"`
+			GRPC                []string      `yaml:"grpc"`
+			REST                []string      `yaml:"rest"`
+			HealthCheckInterval time.Duration `yaml:"health_check_interval"`
+			MaxHeightLag        int64         `yaml:"max_height_lag"`
+			AttemptTimeout      time.Duration `yaml:"attempt_timeout"`
+		} `yaml:"endpoints"`
+		QueryTransport string `yaml:"query_transport"`
+		MaxPages       int    `yaml:"max_pages"`
+		REST           struct {
+			API     string `yaml:"api"`
 			Address string `yaml:"address"`
 		} `yaml:"rest"`
 	} `yaml:"cudos"`
 	Eth struct {
+		Enabled      bool     `yaml:"enabled"`
 		EthNode      string   `yaml:"node"`
 		TokenAddress string   `yaml:"token_address"`
 		EthAccounts  []string `yaml:"accounts"`
 	} `yaml:"eth"`
+	Health struct {
+		MaxStaleness   time.Duration `yaml:"max_staleness"`
+		MaxHeightStall time.Duration `yaml:"max_height_stall"`
+	} `yaml:"health"`
+	Upstream struct {
+		Timeout             time.Duration `yaml:"timeout"`
+		MaxIdleConns        int           `yaml:"max_idle_conns"`
+		MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host"`
+		IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout"`
+		UserAgent           string        `yaml:"user_agent"`
+		TLS                 struct {
+			CAFile             string `yaml:"ca_file"`
+			InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+		} `yaml:"tls"`
+		CudosHeaders map[string]string `yaml:"cudos_headers"`
+		EthHeaders   map[string]string `yaml:"eth_headers"`
+	} `yaml:"upstream"`
+	HTTP struct {
+		CacheMaxAge time.Duration `yaml:"cache_max_age"`
+	} `yaml:"http"`
+	Tasks struct {
+		Supply    TaskConfig `yaml:"supply"`
+		Inflation TaskConfig `yaml:"inflation"`
+		APR       TaskConfig `yaml:"apr"`
+	} `yaml:"tasks"`
 	Calculation struct {
-		InflationSinceDays int64 `yaml:"inflation_since_days"`
+		InflationSinceDays int64             `yaml:"inflation_since_days"`
+		ExcludedAccounts   []ExcludedAccount `yaml:"excluded_accounts"`
+		// FixedExcludedAmount in acudos is subtracted from the circulating supply on top of the excluded accounts
+		FixedExcludedAmount string `yaml:"fixed_excluded_amount"`
 	} `yaml:"calculation"`
 	Storage struct {
-		APRKey                     string `yaml:"apr_key"`
-		APRHeightKey               string `yaml:"apr_height_key"`
-		AnnualProvisionsKey        string `yaml:"annual_provisions"`
-		InflationKey               string `yaml:"inflation_key"`
-		InflationHeightKey         string `yaml:"inflation_height_key"`
-		AllTokensSupplyKey         string `yaml:"all_tokens_supply_key"`
-		SupplyKey                  string `yaml:"supply_key"`
-		SupplyHeightKey            string `yaml:"supply_height_key"`
-		CudosNetworkTotalSupplyKey string `yaml:"cudos_network_total_supply_key"`
+		Backend                    string        `yaml:"backend"`
+		Path                       string        `yaml:"path"`
+		HistoryRetention           time.Duration `yaml:"history_retention"`
+		APRKey                     string        `yaml:"apr_key"`
+		APRHeightKey               string        `yaml:"apr_height_key"`
+		APRNormTimeCheckpointKey   string        `yaml:"apr_norm_time_checkpoint_key"`
+		AnnualProvisionsKey        string        `yaml:"annual_provisions_key"`
+		InflationKey               string        `yaml:"inflation_key"`
+		InflationHeightKey         string        `yaml:"inflation_height_key"`
+		InflationMethodKey         string        `yaml:"inflation_method_key"`
+		AllTokensSupplyKey         string        `yaml:"all_tokens_supply_key"`
+		SupplyKey                  string        `yaml:"supply_key"`
+		SupplyHeightKey            string        `yaml:"supply_height_key"`
+		CudosNetworkTotalSupplyKey string        `yaml:"cudos_network_total_supply_key"`
+		EthSupplyKey               string        `yaml:"eth_supply_key"`
+		EthSupplyHeightKey         string        `yaml:"eth_supply_height_key"`
+		ExcludedAccountsKey        string        `yaml:"excluded_accounts_key"`
 	} `yaml:"storage"`
 }
+
+// TaskConfig sets when a task runs, either by a cron expression or at a fixed interval
+type TaskConfig struct {
+	Cron     string        `yaml:"cron"`
+	Interval time.Duration `yaml:"interval"`
+	Retry    RetryConfig   `yaml:"retry"`
+}
+
+// RetryConfig sets how often a failed task is retried, the backoff doubles after every attempt up to MaxBackoff
+type RetryConfig struct {
+	Attempts       int           `yaml:"attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+}
+
+// ExcludedAccount is a Cudos account whose balance is not part of the circulating supply
+type ExcludedAccount struct {
+	Address  string `yaml:"address"`
+	Category string `yaml:"category"`
+}
+
+// Transports of the bank and distribution queries, REST being used when none is set
+const (
+	QueryTransportREST = "rest"
+	QueryTransportGRPC = "grpc"
+)
+
+// REST APIs of the Cudos node, legacy being used when none is set
+const (
+	RESTAPILegacy  = "legacy"
+	RESTAPIGateway = "gateway"
+)
+
+const (
+	ExcludedAccountCategoryTreasury   = "treasury"
+	ExcludedAccountCategoryVesting    = "vesting"
+	ExcludedAccountCategoryFoundation = "foundation"
+	ExcludedAccountCategoryGravity    = "gravity"
+)
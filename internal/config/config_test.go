@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The least a config needs to be accepted
+const minimalConfig = `
+cudos:
+  endpoints:
+    rpc:
+      - http://127.0.0.1:26657
+    grpc:
+      - http://127.0.0.1:9090
+    rest:
+      - http://127.0.0.1:1317
+calculation:
+  excluded_accounts:
+    - address: cudos1treasury
+      category: treasury
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestNewConfigEthNode(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		node    string
+		wantErr bool
+	}{
+		{name: "http", enabled: true, node: "http://127.0.0.1:8545"},
+		{name: "https", enabled: true, node: "https://rpc.ankr.com/eth"},
+		{name: "websocket", enabled: true, node: "wss://rpc.ankr.com/eth/ws", wantErr: true},
+		{name: "ipc", enabled: true, node: "/var/run/geth.ipc", wantErr: true},
+		{name: "no scheme", enabled: true, node: "rpc.ankr.com/eth", wantErr: true},
+		{name: "empty", enabled: true, node: "", wantErr: true},
+		{name: "disabled", enabled: false, node: "wss://rpc.ankr.com/eth/ws"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eth := "eth:\n  enabled: false\n"
+			if tt.enabled {
+				eth = "eth:\n  enabled: true\n"
+			}
+			eth += "  node: \"" + tt.node + "\"\n"
+
+			_, err := NewConfig(writeConfig(t, minimalConfig+eth))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+
+			if err != nil && !strings.Contains(err.Error(), "eth.node") {
+				t.Errorf("err = %v, want it to name eth.node", err)
+			}
+		})
+	}
+}
+
+func TestNewConfigExcludedAccounts(t *testing.T) {
+	const endpoints = `
+cudos:
+  endpoints:
+    rpc:
+      - http://127.0.0.1:26657
+    grpc:
+      - http://127.0.0.1:9090
+    rest:
+      - http://127.0.0.1:1317
+`
+	tests := []struct {
+		name        string
+		calculation string
+		wantErr     bool
+	}{
+		{name: "treasury account", calculation: "  excluded_accounts:\n    - address: cudos1treasury\n      category: treasury\n"},
+		{name: "gravity account and fixed amount", calculation: "  excluded_accounts:\n    - address: cudos1gravity\n      category: gravity\n  fixed_excluded_amount: \"1942421346000000000000000000\"\n"},
+		{name: "only gravity account", calculation: "  excluded_accounts:\n    - address: cudos1gravity\n      category: gravity\n", wantErr: true},
+		{name: "nothing excluded", calculation: "  inflation_since_days: 50\n", wantErr: true},
+		{name: "invalid category", calculation: "  excluded_accounts:\n    - address: cudos1treasury\n      category: reserve\n", wantErr: true},
+		{name: "invalid fixed amount", calculation: "  fixed_excluded_amount: 1.9B\n", wantErr: true},
+		{name: "negative fixed amount", calculation: "  fixed_excluded_amount: \"-1\"\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfig(writeConfig(t, endpoints+"calculation:\n"+tt.calculation))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewConfigDefaultFile(t *testing.T) {
+	config, err := NewConfig(filepath.Join("..", "..", "config.yaml"))
+	if err != nil {
+		t.Fatalf("the config.yaml the service ships with is rejected: %s", err)
+	}
+
+	if config.Calculation.FixedExcludedAmount != "1942421346000000000000000000" {
+		t.Errorf("fixed excluded amount = %s, want the 1942421346 CUDOS of earlier versions", config.Calculation.FixedExcludedAmount)
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// EnvPrefix starts the names of the environment variables that override config fields
+const EnvPrefix = "CUDOS_STATS_"
+
+// applyEnvOverrides sets every field whose environment variable is set. The variable of a field is named after
+// the yaml keys on its path, e.g. CUDOS_STATS_CUDOS_ENDPOINTS_RPC for cudos.endpoints.rpc. Strings are taken as
+// they are, lists of strings are comma separated and any other value is parsed as YAML like in the config file.
+func applyEnvOverrides(config *Config) error {
+	_, err := applyEnvOverridesToStruct(reflect.ValueOf(config).Elem(), strings.TrimSuffix(EnvPrefix, "_"))
+	return err
+}
+
+func applyEnvOverridesToStruct(value reflect.Value, prefix string) (bool, error) {
+	applied := false
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" || !field.IsExported() {
+			continue
+		}
+
+		ok, err := applyEnvOverride(value.Field(i), prefix+"_"+strings.ToUpper(key))
+		if err != nil {
+			return false, err
+		}
+		applied = applied || ok
+	}
+
+	return applied, nil
+}
+
+func applyEnvOverride(field reflect.Value, name string) (bool, error) {
+	switch {
+	case field.Kind() == reflect.Struct:
+		return applyEnvOverridesToStruct(field, name)
+	case field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct:
+		// Missing sections are only created when one of their fields is overridden
+		target := reflect.New(field.Type().Elem())
+		if !field.IsNil() {
+			target.Elem().Set(field.Elem())
+		}
+
+		applied, err := applyEnvOverridesToStruct(target.Elem(), name)
+		if applied {
+			field.Set(target)
+		}
+		return applied, err
+	}
+
+	env, ok := os.LookupEnv(name)
+	if !ok {
+		return false, nil
+	}
+
+	if field.Kind() == reflect.String {
+		field.SetString(env)
+		return true, nil
+	}
+
+	if field.Type() == reflect.TypeOf([]string{}) {
+		var values []string
+		for _, value := range strings.Split(env, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+		return true, nil
+	}
+
+	target := reflect.New(field.Type())
+	if err := yaml.Unmarshal([]byte(env), target.Interface()); err != nil {
+		return false, fmt.Errorf("invalid value of %s: %s", name, err)
+	}
+	field.Set(target.Elem())
+
+	return true, nil
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		check   func(t *testing.T, config Config)
+		wantErr bool
+	}{
+		{
+			name: "env takes precedence over the file",
+			env:  map[string]string{"CUDOS_STATS_PORT": "4000", "CUDOS_STATS_CUDOS_ENDPOINTS_GRPC": "http://grpc-1:9090"},
+			check: func(t *testing.T, config Config) {
+				if config.Port != 4000 {
+					t.Errorf("port = %d, want 4000", config.Port)
+				}
+				if want := []string{"http://grpc-1:9090"}; !reflect.DeepEqual(config.Cudos.Endpoints.GRPC, want) {
+					t.Errorf("grpc endpoints = %v, want %v", config.Cudos.Endpoints.GRPC, want)
+				}
+			},
+		},
+		{
+			name: "fields without env keep the file values",
+			env:  map[string]string{},
+			check: func(t *testing.T, config Config) {
+				if config.Port != 3000 || config.Eth.EthNode != "https://rpc.ankr.com/eth" {
+					t.Errorf("port = %d, eth node = %s, want the file values", config.Port, config.Eth.EthNode)
+				}
+			},
+		},
+		{
+			name: "comma separated list",
+			env:  map[string]string{"CUDOS_STATS_CUDOS_ENDPOINTS_RPC": " http://rpc-1:26657, http://rpc-2:26657,,"},
+			check: func(t *testing.T, config Config) {
+				if want := []string{"http://rpc-1:26657", "http://rpc-2:26657"}; !reflect.DeepEqual(config.Cudos.Endpoints.RPC, want) {
+					t.Errorf("rpc endpoints = %v, want %v", config.Cudos.Endpoints.RPC, want)
+				}
+			},
+		},
+		{
+			name: "strings are not parsed as YAML",
+			env:  map[string]string{"CUDOS_STATS_UPSTREAM_USER_AGENT": "*", "CUDOS_STATS_STORAGE_BACKEND": "yes"},
+			check: func(t *testing.T, config Config) {
+				if config.Upstream.UserAgent != "*" || config.Storage.Backend != "yes" {
+					t.Errorf("user agent = %q, backend = %q, want \"*\" and \"yes\"", config.Upstream.UserAgent, config.Storage.Backend)
+				}
+			},
+		},
+		{
+			name: "numbers, bools and durations are parsed as YAML",
+			env: map[string]string{
+				"CUDOS_STATS_CUDOS_ENDPOINTS_MAX_HEIGHT_LAG":   "50",
+				"CUDOS_STATS_ETH_ENABLED":                      "true",
+				"CUDOS_STATS_UPSTREAM_TIMEOUT":                 "1m30s",
+				"CUDOS_STATS_TASKS_SUPPLY_RETRY_ATTEMPTS":      "3",
+				"CUDOS_STATS_CALCULATION_INFLATION_SINCE_DAYS": "30",
+			},
+			check: func(t *testing.T, config Config) {
+				if config.Cudos.Endpoints.MaxHeightLag != 50 {
+					t.Errorf("max height lag = %d, want 50", config.Cudos.Endpoints.MaxHeightLag)
+				}
+				if !config.Eth.Enabled {
+					t.Error("eth is not enabled")
+				}
+				if config.Upstream.Timeout != 90*time.Second {
+					t.Errorf("timeout = %s, want 1m30s", config.Upstream.Timeout)
+				}
+				if config.Tasks.Supply.Retry.Attempts != 3 {
+					t.Errorf("retry attempts = %d, want 3", config.Tasks.Supply.Retry.Attempts)
+				}
+				if config.Calculation.InflationSinceDays != 30 {
+					t.Errorf("inflation since days = %d, want 30", config.Calculation.InflationSinceDays)
+				}
+			},
+		},
+		{
+			name: "maps and lists of structs are parsed as YAML",
+			env: map[string]string{
+				"CUDOS_STATS_UPSTREAM_CUDOS_HEADERS":        "{X-Api-Key: secret}",
+				"CUDOS_STATS_CALCULATION_EXCLUDED_ACCOUNTS": "[{address: cudos1vesting, category: vesting}]",
+			},
+			check: func(t *testing.T, config Config) {
+				if want := map[string]string{"X-Api-Key": "secret"}; !reflect.DeepEqual(config.Upstream.CudosHeaders, want) {
+					t.Errorf("cudos headers = %v, want %v", config.Upstream.CudosHeaders, want)
+				}
+				if want := []ExcludedAccount{{Address: "cudos1vesting", Category: "vesting"}}; !reflect.DeepEqual(config.Calculation.ExcludedAccounts, want) {
+					t.Errorf("excluded accounts = %v, want %v", config.Calculation.ExcludedAccounts, want)
+				}
+			},
+		},
+		{
+			name: "missing sections are created when overridden",
+			env:  map[string]string{"CUDOS_STATS_CUDOS_NODE_GRPC_INSECURE": "true"},
+			check: func(t *testing.T, config Config) {
+				if config.Cudos.NodeDetails.GRPC == nil || !config.Cudos.NodeDetails.GRPC.Insecure {
+					t.Errorf("grpc details = %+v, want insecure", config.Cudos.NodeDetails.GRPC)
+				}
+				if config.Cudos.NodeDetails.RPC != nil {
+					t.Errorf("rpc details = %+v, want nil", config.Cudos.NodeDetails.RPC)
+				}
+			},
+		},
+		{
+			name:    "invalid number",
+			env:     map[string]string{"CUDOS_STATS_PORT": "many"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid duration",
+			env:     map[string]string{"CUDOS_STATS_UPSTREAM_TIMEOUT": "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "overridden value is validated",
+			env:     map[string]string{"CUDOS_STATS_CUDOS_QUERY_TRANSPORT": "websocket"},
+			wantErr: true,
+		},
+	}
+
+	path := writeConfig(t, "port: 3000\neth:\n  node: https://rpc.ankr.com/eth\n"+minimalConfig)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			config, err := NewConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+
+			if tt.check != nil {
+				tt.check(t, config)
+			}
+		})
+	}
+}
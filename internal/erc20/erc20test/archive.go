@@ -0,0 +1,97 @@
+package erc20test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// Archive serves calls at past blocks of a simulated backend, which itself only serves its latest block.
+// Every simulated block stands for Stride blocks of the served chain, so a few blocks can span the days
+// a past balance is read at. A block number between two simulated blocks is served by the earlier one.
+type Archive struct {
+	Backend *backends.SimulatedBackend
+	Stride  int64
+}
+
+// HeaderByNumber returns the header of the block with its number on the served chain
+func (a Archive) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, err := a.header(number)
+	if err != nil {
+		return nil, err
+	}
+
+	header = types.CopyHeader(header)
+	header.Number = new(big.Int).Mul(header.Number, big.NewInt(a.Stride))
+	return header, nil
+}
+
+func (a Archive) CodeAt(ctx context.Context, contract common.Address, number *big.Int) ([]byte, error) {
+	statedb, _, err := a.stateAt(number)
+	if err != nil {
+		return nil, err
+	}
+
+	return statedb.GetCode(contract), nil
+}
+
+// CallContract executes the call on the state of the block without changing it
+func (a Archive) CallContract(ctx context.Context, call ethereum.CallMsg, number *big.Int) ([]byte, error) {
+	statedb, header, err := a.stateAt(number)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := a.Backend.Blockchain()
+	msg := types.NewMessage(call.From, call.To, 0, new(big.Int), header.GasLimit, new(big.Int), new(big.Int), new(big.Int), call.Data, nil, true)
+	evm := vm.NewEVM(core.NewEVMBlockContext(header, chain, nil), core.NewEVMTxContext(msg), statedb, chain.Config(), vm.Config{NoBaseFee: true})
+
+	res, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Return(), res.Err
+}
+
+func (a Archive) stateAt(number *big.Int) (*state.StateDB, *types.Header, error) {
+	header, err := a.header(number)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	statedb, err := a.Backend.Blockchain().StateAt(header.Root)
+	if err != nil {
+		return nil, nil, fmt.Errorf("state of block %s is missing: %s", number, err)
+	}
+
+	return statedb, header, nil
+}
+
+// header maps a block number of the served chain to the simulated block standing for it
+func (a Archive) header(number *big.Int) (*types.Header, error) {
+	chain := a.Backend.Blockchain()
+	if number == nil {
+		return chain.CurrentHeader(), nil
+	}
+
+	if number.Sign() < 0 {
+		return nil, fmt.Errorf("block %s is before the genesis", number)
+	}
+
+	header := chain.GetHeaderByNumber(new(big.Int).Div(number, big.NewInt(a.Stride)).Uint64())
+	if header == nil {
+		return nil, fmt.Errorf("block %s is not mined yet", number)
+	}
+
+	return header, nil
+}
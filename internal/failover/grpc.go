@@ -0,0 +1,63 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/grpc/tmservice"
+	"github.com/forbole/juno/v2/node/remote"
+	"google.golang.org/grpc"
+)
+
+// Conn sends every call to a pool of gRPC endpoints, so the query clients created on it fail over between them
+type Conn struct {
+	pool  *Pool
+	conns []*grpc.ClientConn
+}
+
+// NewGRPCConn connects to every endpoint with dialOptions
+func NewGRPCConn(addresses []string, dialOptions []grpc.DialOption, maxHeightLag int64, attemptTimeout time.Duration) (*Conn, error) {
+	conns := make([]*grpc.ClientConn, len(addresses))
+
+	for i, address := range addresses {
+		// Addresses are configured as URLs while gRPC dials host:port
+		conn, err := grpc.Dial(remote.HTTPProtocols.ReplaceAllString(address, ""), dialOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create grpc connection to %s: %s", address, err)
+		}
+
+		conns[i] = conn
+	}
+
+	pool := NewPool("grpc", addresses, maxHeightLag, attemptTimeout, func(ctx context.Context, i int) (int64, error) {
+		res, err := tmservice.NewServiceClient(conns[i]).GetLatestBlock(ctx, &tmservice.GetLatestBlockRequest{})
+		if err != nil {
+			return 0, err
+		}
+
+		if res.Block == nil {
+			return 0, errors.New("latest block response without block")
+		}
+
+		return res.Block.Header.Height, nil
+	})
+
+	return &Conn{pool: pool, conns: conns}, nil
+}
+
+func (c *Conn) Pool() *Pool {
+	return c.pool
+}
+
+func (c *Conn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	return c.pool.Do(ctx, func(ctx context.Context, i int) error {
+		return c.conns[i].Invoke(ctx, method, args, reply, opts...)
+	})
+}
+
+// Streams are not retried, they are opened on the next healthy endpoint
+func (c *Conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return c.conns[c.pool.Pick()].NewStream(ctx, desc, method, opts...)
+}
@@ -0,0 +1,65 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Bounds a check of all endpoints, so a hanging endpoint doesn't hold back the tasks
+const checkTimeout = 10 * time.Second
+
+// Guard checks the endpoints of the pools of all protocols the values are queried over
+type Guard struct {
+	pools []*Pool
+
+	mu         sync.RWMutex
+	lastHeight int64
+	lastErr    error
+}
+
+func NewGuard(pools ...*Pool) *Guard {
+	return &Guard{pools: pools, lastErr: errors.New("endpoints are not checked yet")}
+}
+
+// LastHeight returns the result of the last check without checking the endpoints again
+func (g *Guard) LastHeight() (int64, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.lastHeight, g.lastErr
+}
+
+// LatestHeight checks all endpoints and returns the lowest latest height among the healthy ones,
+// so the values calculated at it can be queried from whichever endpoint a request fails over to
+func (g *Guard) LatestHeight() (int64, error) {
+	height, err := g.check()
+
+	g.mu.Lock()
+	g.lastHeight, g.lastErr = height, err
+	g.mu.Unlock()
+
+	return height, err
+}
+
+func (g *Guard) check() (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	var minHeight int64
+
+	for _, pool := range g.pools {
+		height, err := pool.Check(ctx)
+		if err != nil {
+			return -1, fmt.Errorf("failed to check endpoints: %s", err)
+		}
+
+		if minHeight == 0 || height < minHeight {
+			minHeight = height
+		}
+	}
+
+	return minHeight, nil
+}
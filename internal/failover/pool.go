@@ -0,0 +1,218 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/metrics"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Pool spreads the requests of one protocol over its endpoints and keeps track of which of them are healthy
+type Pool struct {
+	name           string
+	addresses      []string
+	getHeight      func(ctx context.Context, i int) (int64, error)
+	maxHeightLag   int64
+	attemptTimeout time.Duration
+
+	next uint32
+
+	mu        sync.RWMutex
+	endpoints []endpointStatus
+}
+
+type endpointStatus struct {
+	healthy bool
+	height  int64
+}
+
+// NewPool creates a pool of the endpoints at addresses, getHeight returns the latest height of the i-th one.
+// Endpoints more than maxHeightLag blocks behind the highest one are unhealthy, 0 disables the limit.
+// A request to one endpoint is given up on after attemptTimeout, 0 leaves it to the request's context.
+func NewPool(name string, addresses []string, maxHeightLag int64, attemptTimeout time.Duration, getHeight func(ctx context.Context, i int) (int64, error)) *Pool {
+	endpoints := make([]endpointStatus, len(addresses))
+	for i := range endpoints {
+		endpoints[i].healthy = true
+	}
+
+	return &Pool{
+		name:           name,
+		addresses:      addresses,
+		getHeight:      getHeight,
+		maxHeightLag:   maxHeightLag,
+		attemptTimeout: attemptTimeout,
+		endpoints:      endpoints,
+	}
+}
+
+// Do calls fn with the index of an endpoint and the context of the attempt until one succeeds. Endpoints are
+// taken round-robin, the healthy ones first and the unhealthy ones only when all healthy ones failed. When all
+// of them fail, an answer about the request, like a height the endpoints don't have, is returned over a failure.
+func (p *Pool) Do(ctx context.Context, fn func(ctx context.Context, i int) error) error {
+	var err, requestErr error
+
+	for _, i := range p.getOrder() {
+		if err = p.attempt(ctx, i, fn); err == nil {
+			return nil
+		}
+
+		// Retrying on another endpoint won't help a request that was canceled or timed out,
+		// while an attempt that ran out of its own time makes the endpoint unhealthy below
+		if ctx.Err() != nil {
+			return err
+		}
+
+		if isEndpointFailure(err) {
+			p.setStatus(i, false, 0, err)
+		} else if requestErr == nil {
+			requestErr = err
+		}
+
+		log.Warn().Err(err).Str("pool", p.name).Str("endpoint", p.addresses[i]).Msg("Request failed, trying the next endpoint")
+	}
+
+	if requestErr != nil {
+		return requestErr
+	}
+
+	return err
+}
+
+// Every attempt gets its own timeout, so an endpoint that hangs leaves time to try the next one
+func (p *Pool) attempt(ctx context.Context, i int, fn func(ctx context.Context, i int) error) error {
+	if p.attemptTimeout <= 0 {
+		return fn(ctx, i)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.attemptTimeout)
+	defer cancel()
+
+	return fn(ctx, i)
+}
+
+// Pick returns the index of the endpoint to use for a request that can't be retried
+func (p *Pool) Pick() int {
+	return p.getOrder()[0]
+}
+
+// Check gets the latest height of every endpoint, updates which of them are healthy and
+// returns the lowest latest height among the healthy ones
+func (p *Pool) Check(ctx context.Context) (int64, error) {
+	heights := make([]int64, len(p.addresses))
+	errs := make([]error, len(p.addresses))
+
+	var wg sync.WaitGroup
+	for i := range p.addresses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			heights[i], errs[i] = p.getHeight(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	var maxHeight int64
+	for i, height := range heights {
+		if errs[i] == nil && height > maxHeight {
+			maxHeight = height
+		}
+	}
+
+	var minHeight int64
+	var lastErr error
+
+	for i, height := range heights {
+		err := errs[i]
+		if err == nil && p.maxHeightLag > 0 && maxHeight-height > p.maxHeightLag {
+			err = fmt.Errorf("%d blocks behind the highest endpoint", maxHeight-height)
+		} else if err != nil {
+			height = 0
+		}
+
+		p.setStatus(i, err == nil, height, err)
+
+		if err != nil {
+			lastErr = err
+		} else if minHeight == 0 || height < minHeight {
+			minHeight = height
+		}
+	}
+
+	if minHeight == 0 {
+		return 0, fmt.Errorf("no healthy %s endpoint: %s", p.name, lastErr)
+	}
+
+	return minHeight, nil
+}
+
+// The rotation starts one endpoint further on every call so the requests are spread evenly
+func (p *Pool) getOrder() []int {
+	start := int(atomic.AddUint32(&p.next, 1)-1) % len(p.addresses)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	order := make([]int, 0, len(p.addresses))
+	var unhealthy []int
+
+	for n := 0; n < len(p.addresses); n++ {
+		i := (start + n) % len(p.addresses)
+		if p.endpoints[i].healthy {
+			order = append(order, i)
+		} else {
+			unhealthy = append(unhealthy, i)
+		}
+	}
+
+	return append(order, unhealthy...)
+}
+
+// An unknown height of 0 keeps the last known one, err is the reason of an endpoint being unhealthy
+func (p *Pool) setStatus(i int, healthy bool, height int64, err error) {
+	p.mu.Lock()
+	if height <= 0 {
+		height = p.endpoints[i].height
+	}
+	wasHealthy := p.endpoints[i].healthy
+	p.endpoints[i] = endpointStatus{healthy: healthy, height: height}
+	p.mu.Unlock()
+
+	metrics.ObserveEndpoint(p.name, p.addresses[i], healthy, height)
+
+	switch {
+	case wasHealthy && !healthy:
+		log.Warn().Err(err).Str("pool", p.name).Str("endpoint", p.addresses[i]).Msg("Endpoint is unhealthy")
+	case !wasHealthy && healthy:
+		log.Info().Str("pool", p.name).Str("endpoint", p.addresses[i]).Msg("Endpoint is healthy again")
+	}
+}
+
+// Errors for requests the endpoint itself couldn't serve, as opposed to answers about the request,
+// like a height it doesn't have, make it unhealthy until the next health check
+func isEndpointFailure(err error) bool {
+	var heightErr *rest.HeightError
+	if errors.As(err, &heightErr) {
+		return false
+	}
+
+	var statusErr *rest.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode != http.StatusBadRequest
+	}
+
+	// The gRPC connections see the status errors before they are converted to the typed errors
+	if s, ok := status.FromError(err); ok {
+		return s.Code() != codes.InvalidArgument && rest.ParseHeightError(s.Message()) == nil
+	}
+
+	return true
+}
@@ -0,0 +1,135 @@
+package failover
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+)
+
+func newTestPool(attemptTimeout time.Duration, heights ...int64) *Pool {
+	addresses := make([]string, len(heights))
+	for i := range addresses {
+		addresses[i] = "endpoint-" + string(rune('a'+i))
+	}
+
+	return NewPool("test", addresses, 0, attemptTimeout, func(ctx context.Context, i int) (int64, error) {
+		if heights[i] == 0 {
+			return 0, errors.New("unreachable")
+		}
+		return heights[i], nil
+	})
+}
+
+func TestDoRetriesAttemptThatTimedOut(t *testing.T) {
+	// The rotation of a new pool starts at the first endpoint
+	pool := newTestPool(20*time.Millisecond, 100, 100)
+
+	var tried []int
+	err := pool.Do(context.Background(), func(ctx context.Context, i int) error {
+		tried = append(tried, i)
+		if i == 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tried) != 2 || tried[0] != 0 || tried[1] != 1 {
+		t.Errorf("tried endpoints %v, want [0 1]", tried)
+	}
+
+	if pool.endpoints[0].healthy {
+		t.Error("endpoint that timed out is still healthy")
+	}
+}
+
+func TestDoStopsWhenRequestIsCanceled(t *testing.T) {
+	pool := newTestPool(time.Second, 100, 100)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	err := pool.Do(ctx, func(ctx context.Context, i int) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if calls != 1 {
+		t.Errorf("endpoints tried %d times, want 1", calls)
+	}
+}
+
+func TestDoTriesOtherEndpointsForPrunedHeight(t *testing.T) {
+	pool := newTestPool(0, 100, 100, 100)
+
+	pruned := &rest.HeightError{Height: 10, LatestHeight: 100, Err: rest.ErrHeightPruned}
+
+	err := pool.Do(context.Background(), func(ctx context.Context, i int) error {
+		switch i {
+		case 0:
+			return pruned
+		case 1:
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !pool.endpoints[0].healthy {
+		t.Error("endpoint without the height became unhealthy")
+	}
+	if pool.endpoints[1].healthy {
+		t.Error("failed endpoint is still healthy")
+	}
+
+	// When no endpoint has the height the answer about the height wins over the failures
+	err = pool.Do(context.Background(), func(ctx context.Context, i int) error {
+		if i == 0 {
+			return pruned
+		}
+		return errors.New("connection refused")
+	})
+	if !errors.Is(err, rest.ErrHeightPruned) {
+		t.Errorf("err = %v, want %v", err, rest.ErrHeightPruned)
+	}
+}
+
+func TestGuardLastHeight(t *testing.T) {
+	rpc := newTestPool(0, 100, 90)
+	grpc := newTestPool(0, 95)
+	guard := NewGuard(rpc, grpc)
+
+	if _, err := guard.LastHeight(); err == nil {
+		t.Error("expected an error before the first check")
+	}
+
+	height, err := guard.LatestHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != 90 {
+		t.Errorf("latest height = %d, want 90", height)
+	}
+
+	lastHeight, err := guard.LastHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastHeight != height {
+		t.Errorf("last height = %d, want %d", lastHeight, height)
+	}
+}
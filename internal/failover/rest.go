@@ -0,0 +1,71 @@
+package failover
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/distribution"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// NewRESTPool creates a pool of REST endpoints whose latest height is read from latestBlockPath
+func NewRESTPool(client *http.Client, addresses []string, latestBlockPath string, maxHeightLag int64, attemptTimeout time.Duration) *Pool {
+	return NewPool("rest", addresses, maxHeightLag, attemptTimeout, func(ctx context.Context, i int) (int64, error) {
+		return rest.GetLatestHeight(ctx, client, addresses[i]+latestBlockPath)
+	})
+}
+
+// bankClient sends every query to the client of one of the pool's endpoints, clients[i] querying the i-th one
+type bankClient struct {
+	pool    *Pool
+	clients []bank.Client
+}
+
+func NewBankClient(pool *Pool, clients []bank.Client) *bankClient {
+	return &bankClient{pool: pool, clients: clients}
+}
+
+func (c *bankClient) GetTotalSupply(ctx context.Context, height int64) (bank.TotalSupplyResponse, error) {
+	var res bank.TotalSupplyResponse
+
+	err := c.pool.Do(ctx, func(ctx context.Context, i int) (err error) {
+		res, err = c.clients[i].GetTotalSupply(ctx, height)
+		return err
+	})
+
+	return res, err
+}
+
+func (c *bankClient) GetBalance(ctx context.Context, height int64, address, denom string) (sdk.Coin, error) {
+	var res sdk.Coin
+
+	err := c.pool.Do(ctx, func(ctx context.Context, i int) (err error) {
+		res, err = c.clients[i].GetBalance(ctx, height, address, denom)
+		return err
+	})
+
+	return res, err
+}
+
+type distributionClient struct {
+	pool    *Pool
+	clients []distribution.Client
+}
+
+func NewDistributionClient(pool *Pool, clients []distribution.Client) *distributionClient {
+	return &distributionClient{pool: pool, clients: clients}
+}
+
+func (c *distributionClient) GetParams(ctx context.Context) (distribution.ParametersResponse, error) {
+	var res distribution.ParametersResponse
+
+	err := c.pool.Do(ctx, func(ctx context.Context, i int) (err error) {
+		res, err = c.clients[i].GetParams(ctx)
+		return err
+	})
+
+	return res, err
+}
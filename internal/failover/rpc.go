@@ -0,0 +1,32 @@
+package failover
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	rpchttp "github.com/tendermint/tendermint/rpc/client/http"
+)
+
+// NewRPCPool creates a pool of Tendermint RPC endpoints, which are only asked for their latest height over client
+func NewRPCPool(addresses []string, client *http.Client, maxHeightLag int64) (*Pool, error) {
+	clients := make([]*rpchttp.HTTP, len(addresses))
+
+	for i, address := range addresses {
+		var err error
+		clients[i], err = rpchttp.NewWithClient(address, "/websocket", client)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create rpc client for %s: %s", address, err)
+		}
+	}
+
+	// The pool only checks the endpoints, which the check timeout bounds
+	return NewPool("rpc", addresses, maxHeightLag, 0, func(ctx context.Context, i int) (int64, error) {
+		status, err := clients[i].Status(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		return status.SyncInfo.LatestBlockHeight, nil
+	}), nil
+}
@@ -0,0 +1,75 @@
+package grpcclient
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// bankClient queries the bank module over the same gRPC connection as the staking queries
+type bankClient struct {
+	client   banktypes.QueryClient
+	maxPages int
+}
+
+func NewBankClient(conn grpc.ClientConnInterface, maxPages int) *bankClient {
+	return &bankClient{client: banktypes.NewQueryClient(conn), maxPages: maxPages}
+}
+
+func (c *bankClient) GetTotalSupply(ctx context.Context, height int64) (bank.TotalSupplyResponse, error) {
+	ctx = withHeight(ctx, height)
+
+	return bank.GetAllPages(c.maxPages, func(key []byte) (bank.TotalSupplyResponse, error) {
+		res, err := c.client.TotalSupply(ctx, &banktypes.QueryTotalSupplyRequest{Pagination: &query.PageRequest{Key: key}})
+		if err != nil {
+			return bank.TotalSupplyResponse{}, convertError(err)
+		}
+
+		var page bank.PageResponse
+		if res.Pagination != nil {
+			page = bank.PageResponse{NextKey: res.Pagination.NextKey, Total: strconv.FormatUint(res.Pagination.Total, 10)}
+		}
+
+		return bank.TotalSupplyResponse{Supply: res.Supply, Pagination: page}, nil
+	})
+}
+
+// Accounts without a balance of the denom are returned with a zero amount
+func (c *bankClient) GetBalance(ctx context.Context, height int64, address, denom string) (sdk.Coin, error) {
+	res, err := c.client.Balance(withHeight(ctx, height), &banktypes.QueryBalanceRequest{Address: address, Denom: denom})
+	if err != nil {
+		return sdk.Coin{}, convertError(err)
+	}
+
+	if res.Balance == nil {
+		return sdk.NewCoin(denom, sdk.ZeroInt()), nil
+	}
+
+	return *res.Balance, nil
+}
+
+// Without the header the latest height is queried, the same as height 0 of the REST endpoints
+func withHeight(ctx context.Context, height int64) context.Context {
+	if height <= 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, grpctypes.GRPCBlockHeightHeader, strconv.FormatInt(height, 10))
+}
+
+// Heights the node doesn't have are reported with the same typed errors as by the REST clients
+func convertError(err error) error {
+	if heightErr := rest.ParseHeightError(status.Convert(err).Message()); heightErr != nil {
+		return heightErr
+	}
+	return err
+}
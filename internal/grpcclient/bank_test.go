@@ -0,0 +1,189 @@
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	grpctypes "github.com/cosmos/cosmos-sdk/types/grpc"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const prunedHeightMessage = "failed to load state at height 100; version does not exist (latest height: 5000)"
+
+// testNode answers like a Cudos node and records the height metadata of every call
+type testNode struct {
+	err     error
+	heights [][]string
+}
+
+type testBankServer struct {
+	banktypes.UnimplementedQueryServer
+	*testNode
+}
+
+type testDistributionServer struct {
+	distributiontypes.UnimplementedQueryServer
+	*testNode
+}
+
+func (n *testNode) record(ctx context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	n.heights = append(n.heights, md.Get(grpctypes.GRPCBlockHeightHeader))
+}
+
+// The supply comes in two pages, the second one after the key "btoken"
+func (n *testBankServer) TotalSupply(ctx context.Context, req *banktypes.QueryTotalSupplyRequest) (*banktypes.QueryTotalSupplyResponse, error) {
+	n.record(ctx)
+	if n.err != nil {
+		return nil, n.err
+	}
+
+	if req.Pagination == nil || len(req.Pagination.Key) == 0 {
+		return &banktypes.QueryTotalSupplyResponse{
+			Supply:     sdk.NewCoins(sdk.NewInt64Coin("acudos", 10)),
+			Pagination: &query.PageResponse{NextKey: []byte("btoken")},
+		}, nil
+	}
+
+	return &banktypes.QueryTotalSupplyResponse{
+		Supply:     sdk.NewCoins(sdk.NewInt64Coin("btoken", 2)),
+		Pagination: &query.PageResponse{},
+	}, nil
+}
+
+func (n *testBankServer) Balance(ctx context.Context, req *banktypes.QueryBalanceRequest) (*banktypes.QueryBalanceResponse, error) {
+	n.record(ctx)
+	if n.err != nil {
+		return nil, n.err
+	}
+
+	coin := sdk.NewInt64Coin(req.Denom, 5)
+	return &banktypes.QueryBalanceResponse{Balance: &coin}, nil
+}
+
+func (n *testDistributionServer) Params(ctx context.Context, _ *distributiontypes.QueryParamsRequest) (*distributiontypes.QueryParamsResponse, error) {
+	n.record(ctx)
+	if n.err != nil {
+		return nil, n.err
+	}
+
+	return &distributiontypes.QueryParamsResponse{Params: distributiontypes.DefaultParams()}, nil
+}
+
+func newTestNodeConn(t *testing.T, node *testNode) *grpc.ClientConn {
+	t.Helper()
+
+	server := grpc.NewServer()
+	banktypes.RegisterQueryServer(server, &testBankServer{testNode: node})
+	distributiontypes.RegisterQueryServer(server, &testDistributionServer{testNode: node})
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestBankClientHeightMetadata(t *testing.T) {
+	tests := []struct {
+		name       string
+		height     int64
+		wantHeight []string
+	}{
+		{name: "past height", height: 100, wantHeight: []string{"100"}},
+		{name: "latest height", height: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &testNode{}
+			client := NewBankClient(newTestNodeConn(t, node), 0)
+
+			supply, err := client.GetTotalSupply(context.Background(), tt.height)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(supply.Supply) != 2 {
+				t.Errorf("supply = %s, want both pages", supply.Supply)
+			}
+
+			balance, err := client.GetBalance(context.Background(), tt.height, "cudos1account", "acudos")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !balance.Amount.Equal(sdk.NewInt(5)) {
+				t.Errorf("balance = %s, want 5acudos", balance)
+			}
+
+			// Both supply pages and the balance
+			if len(node.heights) != 3 {
+				t.Fatalf("node was called %d times, want 3", len(node.heights))
+			}
+			for _, height := range node.heights {
+				if len(height) != len(tt.wantHeight) || (len(height) == 1 && height[0] != tt.wantHeight[0]) {
+					t.Errorf("height metadata = %v, want %v", height, tt.wantHeight)
+				}
+			}
+		})
+	}
+}
+
+func TestConvertError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantHeight error
+		wantCode   codes.Code
+	}{
+		{name: "pruned height", err: status.Error(codes.InvalidArgument, prunedHeightMessage), wantHeight: rest.ErrHeightPruned},
+		{name: "height above the latest one", err: status.Error(codes.InvalidArgument, "failed to load state at height 6000; version does not exist (latest height: 5000)"), wantHeight: rest.ErrHeightUnknown},
+		{name: "other error", err: status.Error(codes.Unavailable, "connection refused"), wantCode: codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &testNode{err: tt.err}
+			conn := newTestNodeConn(t, node)
+
+			_, bankErr := NewBankClient(conn, 0).GetBalance(context.Background(), 100, "cudos1account", "acudos")
+			_, distributionErr := NewDistributionClient(conn).GetParams(context.Background())
+
+			for _, err := range []error{bankErr, distributionErr} {
+				if tt.wantHeight != nil {
+					var heightErr *rest.HeightError
+					if !errors.As(err, &heightErr) || !errors.Is(err, tt.wantHeight) {
+						t.Errorf("err = %v, want a height error %v", err, tt.wantHeight)
+					}
+					continue
+				}
+
+				if status.Code(err) != tt.wantCode {
+					t.Errorf("err = %v, want code %s", err, tt.wantCode)
+				}
+			}
+		})
+	}
+}
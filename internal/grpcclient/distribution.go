@@ -0,0 +1,26 @@
+package grpcclient
+
+import (
+	"context"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/distribution"
+	distributiontypes "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	"google.golang.org/grpc"
+)
+
+type distributionClient struct {
+	client distributiontypes.QueryClient
+}
+
+func NewDistributionClient(conn grpc.ClientConnInterface) *distributionClient {
+	return &distributionClient{client: distributiontypes.NewQueryClient(conn)}
+}
+
+func (c *distributionClient) GetParams(ctx context.Context) (distribution.ParametersResponse, error) {
+	res, err := c.client.Params(ctx, &distributiontypes.QueryParamsRequest{})
+	if err != nil {
+		return distribution.ParametersResponse{}, convertError(err)
+	}
+
+	return distribution.ParametersResponse{CommunityTax: res.Params.CommunityTax.String()}, nil
+}
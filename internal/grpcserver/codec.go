@@ -0,0 +1,32 @@
+package grpcserver
+
+import (
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/codec"
+)
+
+// gogoCodec encodes the gogoproto generated SDK types with their own methods, because their
+// custom types such as sdk.Dec can't be handled by the reflection based default codec
+type gogoCodec struct{}
+
+func (gogoCodec) Marshal(v interface{}) ([]byte, error) {
+	message, ok := v.(codec.ProtoMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal %T: not a gogoproto message", v)
+	}
+	return message.Marshal()
+}
+
+func (gogoCodec) Unmarshal(data []byte, v interface{}) error {
+	message, ok := v.(codec.ProtoMarshaler)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal %T: not a gogoproto message", v)
+	}
+	return message.Unmarshal(data)
+}
+
+// Clients keep sending the standard content subtype
+func (gogoCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,185 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/metrics"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/pagination"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/tasks"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata key used by Cosmos SDK clients to query state at a past height
+const blockHeightMetadata = "x-cosmos-block-height"
+
+// NewServer serves the mint queries Cudos doesn't have and the bank supply queries from the calculated values
+func NewServer(cfg config.Config, storage keyValueStorage) (*grpc.Server, error) {
+	blocksPerYear, err := tasks.GetBlocksPerYear(cfg.InflationGenesis.BlocksPerDay)
+	if err != nil {
+		return nil, err
+	}
+
+	server := grpc.NewServer(grpc.ForceServerCodec(gogoCodec{}), grpc.UnaryInterceptor(metrics.GRPCUnaryInterceptor))
+
+	minttypes.RegisterQueryServer(server, &mintQueryServer{
+		cfg:     cfg,
+		storage: storage,
+		params: minttypes.Params{
+			MintDenom:           cfg.InflationGenesis.MintDenom,
+			InflationRateChange: sdk.ZeroDec(),
+			InflationMax:        sdk.ZeroDec(),
+			InflationMin:        sdk.ZeroDec(),
+			GoalBonded:          sdk.ZeroDec(),
+			BlocksPerYear:       blocksPerYear,
+		},
+	})
+
+	banktypes.RegisterQueryServer(server, &bankQueryServer{cfg: cfg, storage: storage})
+
+	return server, nil
+}
+
+// mintQueryServer reports the same params as the REST params endpoint
+type mintQueryServer struct {
+	minttypes.UnimplementedQueryServer
+
+	cfg     config.Config
+	storage keyValueStorage
+	params  minttypes.Params
+}
+
+func (s *mintQueryServer) Params(ctx context.Context, _ *minttypes.QueryParamsRequest) (*minttypes.QueryParamsResponse, error) {
+	return &minttypes.QueryParamsResponse{Params: s.params}, nil
+}
+
+func (s *mintQueryServer) Inflation(ctx context.Context, _ *minttypes.QueryInflationRequest) (*minttypes.QueryInflationResponse, error) {
+	inflation, err := getDecValue(ctx, s.storage, s.cfg.Storage.InflationKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &minttypes.QueryInflationResponse{Inflation: inflation}, nil
+}
+
+func (s *mintQueryServer) AnnualProvisions(ctx context.Context, _ *minttypes.QueryAnnualProvisionsRequest) (*minttypes.QueryAnnualProvisionsResponse, error) {
+	annualProvisions, err := getDecValue(ctx, s.storage, s.cfg.Storage.AnnualProvisionsKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &minttypes.QueryAnnualProvisionsResponse{AnnualProvisions: annualProvisions}, nil
+}
+
+// bankQueryServer serves the supply with the circulating supply in place of the total supply of the mint denom
+type bankQueryServer struct {
+	banktypes.UnimplementedQueryServer
+
+	cfg     config.Config
+	storage keyValueStorage
+}
+
+func (s *bankQueryServer) TotalSupply(ctx context.Context, req *banktypes.QueryTotalSupplyRequest) (*banktypes.QueryTotalSupplyResponse, error) {
+	supply, err := s.getSupply(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, pageResponse, err := pagination.PaginateCoins(supply, req.Pagination)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &banktypes.QueryTotalSupplyResponse{Supply: page, Pagination: pageResponse}, nil
+}
+
+// Like the bank module, a denom without supply is reported with a zero amount
+func (s *bankQueryServer) SupplyOf(ctx context.Context, req *banktypes.QuerySupplyOfRequest) (*banktypes.QuerySupplyOfResponse, error) {
+	if err := sdk.ValidateDenom(req.Denom); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	supply, err := s.getSupply(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &banktypes.QuerySupplyOfResponse{Amount: sdk.NewCoin(req.Denom, supply.AmountOf(req.Denom))}, nil
+}
+
+func (s *bankQueryServer) getSupply(ctx context.Context) (sdk.Coins, error) {
+	value, err := getValue(ctx, s.storage, s.cfg.Storage.AllTokensSupplyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var supply bank.TotalSupplyResponse
+	if err := json.Unmarshal([]byte(value), &supply); err != nil {
+		return nil, internalError(fmt.Errorf("failed to decode stored supply: %s", err))
+	}
+
+	return supply.Supply, nil
+}
+
+func getDecValue(ctx context.Context, storage keyValueStorage, key string) (sdk.Dec, error) {
+	value, err := getValue(ctx, storage, key)
+	if err != nil {
+		return sdk.Dec{}, err
+	}
+
+	dec, err := sdk.NewDecFromStr(value)
+	if err != nil {
+		return sdk.Dec{}, internalError(fmt.Errorf("failed to parse stored value %s of %s: %s", value, key, err))
+	}
+
+	return dec, nil
+}
+
+// Only the latest values are served over gRPC, past heights are available through the REST endpoints
+func getValue(ctx context.Context, storage keyValueStorage, key string) (string, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, height := range md.Get(blockHeightMetadata) {
+			if height != "" && height != "0" {
+				return "", status.Errorf(codes.InvalidArgument, "queries at height %s are only supported by the REST endpoints", height)
+			}
+		}
+	}
+
+	value, err := storage.GetValue(key)
+	if err != nil {
+		return "", storageError(err)
+	}
+
+	return value, nil
+}
+
+// Mirrors the HTTP error model, values that are not calculated yet are unavailable and anything else is internal
+func storageError(err error) error {
+	if errors.Is(err, storage.ErrKeyNotFound) {
+		return status.Error(codes.Unavailable, "value is not calculated yet")
+	}
+
+	return internalError(err)
+}
+
+// Internal error details are only logged
+func internalError(err error) error {
+	log.Error().Err(err).Send()
+	return status.Error(codes.Internal, "internal error")
+}
+
+type keyValueStorage interface {
+	GetValue(key string) (string, error)
+}
@@ -0,0 +1,229 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+	banktypes "github.com/cosmos/cosmos-sdk/x/bank/types"
+	minttypes "github.com/cosmos/cosmos-sdk/x/mint/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestConfig() config.Config {
+	var cfg config.Config
+	cfg.InflationGenesis.BlocksPerDay = "17280"
+	cfg.InflationGenesis.MintDenom = "acudos"
+	cfg.Storage.InflationKey = "inflation"
+	cfg.Storage.AnnualProvisionsKey = "annual_provisions"
+	cfg.Storage.AllTokensSupplyKey = "all_tokens_supply"
+	return cfg
+}
+
+// newTestConn serves the storage over an in-memory listener and dials it
+func newTestConn(t *testing.T, store keyValueStorage) *grpc.ClientConn {
+	t.Helper()
+
+	server, err := NewServer(newTestConfig(), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(gogoCodec{})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func newCalculatedStorage(t *testing.T) keyValueStorage {
+	t.Helper()
+
+	supply, err := json.Marshal(bank.TotalSupplyResponse{Supply: sdk.NewCoins(
+		sdk.NewInt64Coin("acudos", 1000),
+		sdk.NewInt64Coin("btoken", 2),
+		sdk.NewInt64Coin("ctoken", 3),
+	)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := newTestConfig()
+	store := storage.NewStorage()
+	if err := store.SetValues(map[string]string{
+		cfg.Storage.InflationKey:        "0.123",
+		cfg.Storage.AnnualProvisionsKey: "456.5",
+		cfg.Storage.AllTokensSupplyKey:  string(supply),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func TestMintQueries(t *testing.T) {
+	client := minttypes.NewQueryClient(newTestConn(t, newCalculatedStorage(t)))
+	ctx := context.Background()
+
+	params, err := client.Params(ctx, &minttypes.QueryParamsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Params.MintDenom != "acudos" || params.Params.BlocksPerYear != 6311520 {
+		t.Errorf("params = %+v, want acudos and 6311520 blocks per year", params.Params)
+	}
+
+	inflation, err := client.Inflation(ctx, &minttypes.QueryInflationRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inflation.Inflation.Equal(sdk.MustNewDecFromStr("0.123")) {
+		t.Errorf("inflation = %s, want 0.123", inflation.Inflation)
+	}
+
+	annualProvisions, err := client.AnnualProvisions(ctx, &minttypes.QueryAnnualProvisionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !annualProvisions.AnnualProvisions.Equal(sdk.MustNewDecFromStr("456.5")) {
+		t.Errorf("annual provisions = %s, want 456.5", annualProvisions.AnnualProvisions)
+	}
+}
+
+func TestTotalSupply(t *testing.T) {
+	client := banktypes.NewQueryClient(newTestConn(t, newCalculatedStorage(t)))
+
+	tests := []struct {
+		name        string
+		pagination  *query.PageRequest
+		wantDenoms  []string
+		wantNextKey string
+		wantTotal   uint64
+		wantCode    codes.Code
+	}{
+		{name: "all", wantDenoms: []string{"acudos", "btoken", "ctoken"}, wantTotal: 3},
+		{name: "first page", pagination: &query.PageRequest{Limit: 2, CountTotal: true}, wantDenoms: []string{"acudos", "btoken"}, wantNextKey: "ctoken", wantTotal: 3},
+		{name: "next page", pagination: &query.PageRequest{Key: []byte("ctoken"), Limit: 2}, wantDenoms: []string{"ctoken"}},
+		{name: "key and offset", pagination: &query.PageRequest{Key: []byte("ctoken"), Offset: 1}, wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.TotalSupply(context.Background(), &banktypes.QueryTotalSupplyRequest{Pagination: tt.pagination})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("err = %v, want code %s", err, tt.wantCode)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(res.Supply) != len(tt.wantDenoms) {
+				t.Fatalf("supply = %s, want denoms %v", res.Supply, tt.wantDenoms)
+			}
+			for i, coin := range res.Supply {
+				if coin.Denom != tt.wantDenoms[i] {
+					t.Fatalf("supply = %s, want denoms %v", res.Supply, tt.wantDenoms)
+				}
+			}
+
+			if string(res.Pagination.NextKey) != tt.wantNextKey || res.Pagination.Total != tt.wantTotal {
+				t.Errorf("pagination = %q %d, want %q %d", res.Pagination.NextKey, res.Pagination.Total, tt.wantNextKey, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestSupplyOf(t *testing.T) {
+	client := banktypes.NewQueryClient(newTestConn(t, newCalculatedStorage(t)))
+
+	tests := []struct {
+		denom    string
+		want     sdk.Coin
+		wantCode codes.Code
+	}{
+		{denom: "acudos", want: sdk.NewInt64Coin("acudos", 1000)},
+		{denom: "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", want: sdk.NewInt64Coin("ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", 0)},
+		{denom: "1invalid", wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.denom, func(t *testing.T) {
+			res, err := client.SupplyOf(context.Background(), &banktypes.QuerySupplyOfRequest{Denom: tt.denom})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("err = %v, want code %s", err, tt.wantCode)
+			}
+
+			if err == nil && (res.Amount.Denom != tt.want.Denom || !res.Amount.Amount.Equal(tt.want.Amount)) {
+				t.Errorf("amount = %s, want %s", res.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnavailableBeforeFirstCalculation(t *testing.T) {
+	conn := newTestConn(t, storage.NewStorage())
+	mintClient := minttypes.NewQueryClient(conn)
+	bankClient := banktypes.NewQueryClient(conn)
+	ctx := context.Background()
+
+	_, err := mintClient.Inflation(ctx, &minttypes.QueryInflationRequest{})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("inflation err = %v, want code %s", err, codes.Unavailable)
+	}
+
+	_, err = mintClient.AnnualProvisions(ctx, &minttypes.QueryAnnualProvisionsRequest{})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("annual provisions err = %v, want code %s", err, codes.Unavailable)
+	}
+
+	_, err = bankClient.TotalSupply(ctx, &banktypes.QueryTotalSupplyRequest{})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("total supply err = %v, want code %s", err, codes.Unavailable)
+	}
+}
+
+func TestBlockHeightMetadata(t *testing.T) {
+	client := minttypes.NewQueryClient(newTestConn(t, newCalculatedStorage(t)))
+
+	tests := []struct {
+		height   string
+		wantCode codes.Code
+	}{
+		{height: "0", wantCode: codes.OK},
+		{height: "100", wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.height, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), blockHeightMetadata, tt.height)
+
+			_, err := client.Inflation(ctx, &minttypes.QueryInflationRequest{})
+			if status.Code(err) != tt.wantCode {
+				t.Errorf("err = %v, want code %s", err, tt.wantCode)
+			}
+		})
+	}
+}
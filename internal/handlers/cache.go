@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+)
+
+// writeCachedJSON encodes the response before any caching header is set, so a failure never goes out as cacheable
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, cfg config.Config, updatedAt time.Time, response interface{}) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	writeCachedBody(w, r, cfg, updatedAt, "application/json", append(body, '\n'))
+}
+
+func writeCachedText(w http.ResponseWriter, r *http.Request, cfg config.Config, updatedAt time.Time, text string) {
+	writeCachedBody(w, r, cfg, updatedAt, "text/html", []byte(text))
+}
+
+func writeCachedBody(w http.ResponseWriter, r *http.Request, cfg config.Config, updatedAt time.Time, contentType string, body []byte) {
+	if writeCacheHeaders(w, r, cfg, updatedAt) {
+		return
+	}
+
+	writeBody(w, http.StatusOK, contentType, body)
+}
+
+// writeCacheHeaders sets the caching headers of a response built from values written at updatedAt and reports
+// whether the client's copy is still current, in which case 304 has already been written.
+func writeCacheHeaders(w http.ResponseWriter, r *http.Request, cfg config.Config, updatedAt time.Time) bool {
+	etag := getETag(r, updatedAt)
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", blockHeightHeader)
+	setCacheControl(w, cfg)
+
+	// A history without points has no modification time
+	if !updatedAt.IsZero() {
+		w.Header().Set("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+	}
+
+	if !isNotModified(r, etag, updatedAt) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+func setCacheControl(w http.ResponseWriter, cfg config.Config) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int64(cfg.HTTP.CacheMaxAge.Seconds())))
+}
+
+// The tag changes whenever the values are rewritten and differs between routes, query parameters and requested heights
+func getETag(r *http.Request, updatedAt time.Time) string {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s|%s|%d", r.URL.RequestURI(), r.Header.Get(blockHeightHeader), updatedAt.UnixNano())
+	return fmt.Sprintf(`"%x"`, hash.Sum64())
+}
+
+// If-None-Match takes precedence over If-Modified-Since as required by RFC 7232
+func isNotModified(r *http.Request, etag string, updatedAt time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	ifModifiedSince := r.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || updatedAt.IsZero() {
+		return false
+	}
+
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+
+	// Last-Modified is sent with second precision
+	return !updatedAt.Truncate(time.Second).After(since)
+}
+
+func getLatestUpdate(entries []storage.Entry) time.Time {
+	var latest time.Time
+	for _, entry := range entries {
+		if entry.UpdatedAt.After(latest) {
+			latest = entry.UpdatedAt
+		}
+	}
+	return latest
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+)
+
+func TestNotModified(t *testing.T) {
+	cfg := newTestConfig()
+	store := storage.NewStorage()
+
+	if err := store.SetValues(map[string]string{
+		cfg.Storage.InflationKey:       "0.1",
+		cfg.Storage.InflationHeightKey: "100",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := GetInflationHandler(cfg, store, testCalculator{})
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/cosmos/mint/v1beta1/inflation", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	if etag == "" || lastModified == "" {
+		t.Fatalf("ETag = %q, Last-Modified = %q, want both set", etag, lastModified)
+	}
+
+	modifiedAt, err := http.ParseTime(lastModified)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{name: "matching tag", headers: map[string]string{"If-None-Match": etag}, wantStatus: http.StatusNotModified},
+		{name: "weak matching tag in a list", headers: map[string]string{"If-None-Match": `"other", W/` + etag}, wantStatus: http.StatusNotModified},
+		{name: "any tag", headers: map[string]string{"If-None-Match": "*"}, wantStatus: http.StatusNotModified},
+		{name: "other tag", headers: map[string]string{"If-None-Match": `"other"`}, wantStatus: http.StatusOK},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": lastModified}, wantStatus: http.StatusNotModified},
+		{name: "modified since", headers: map[string]string{"If-Modified-Since": modifiedAt.Add(-time.Second).Format(http.TimeFormat)}, wantStatus: http.StatusOK},
+		{name: "invalid date", headers: map[string]string{"If-Modified-Since": "yesterday"}, wantStatus: http.StatusOK},
+		{
+			name:       "tag takes precedence over date",
+			headers:    map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": lastModified},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/cosmos/mint/v1beta1/inflation", nil)
+			for name, value := range tt.headers {
+				r.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if w.Header().Get("ETag") != etag {
+				t.Errorf("ETag = %s, want %s", w.Header().Get("ETag"), etag)
+			}
+
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("body = %s, want none", w.Body)
+			}
+		})
+	}
+}
@@ -5,217 +5,326 @@ import (
 	"fmt"
 	"math/big"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
-	"github.com/rs/zerolog/log"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/tasks"
 )
 
 func GetCircSupplyTextHandler(cfg config.Config, storage keyValueStorage) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		supply, err := storage.GetValue(cfg.Storage.SupplyKey)
+		supply, _, updatedAt, err := getValueAtHeight(storage, cfg.Storage.SupplyKey, cfg.Storage.SupplyHeightKey)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
 		formattedSupply, err := formatSupply(supply)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "text/html")
-		w.WriteHeader(http.StatusOK)
-
-		if _, err := w.Write([]byte(formattedSupply)); err != nil {
-			badRequest(w, err)
-			return
-		}
+		writeCachedText(w, r, cfg, updatedAt, formattedSupply)
 	}
 }
 
 func GetCircSupplyJSONHandler(cfg config.Config, storage keyValueStorage) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		supply, err := storage.GetValue(cfg.Storage.SupplyKey)
+		supply, height, updatedAt, err := getValueAtHeight(storage, cfg.Storage.SupplyKey, cfg.Storage.SupplyHeightKey)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
 		formattedSupply, err := formatSupply(supply)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
-		setHeaders(w)
-
-		if err := json.NewEncoder(w).Encode(supplyResponse{Supply: formattedSupply}); err != nil {
-			badRequest(w, err)
-		}
+		writeCachedJSON(w, r, cfg, updatedAt, supplyResponse{Supply: formattedSupply, Height: height, UpdatedAt: updatedAt})
 	}
 }
 
 func GetStatsHandler(cfg config.Config, storage keyValueStorage) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		supply, err := storage.GetValue(cfg.Storage.SupplyKey)
-		if err != nil {
-			badRequest(w, err)
-			return
+		keys := []string{
+			cfg.Storage.SupplyKey,
+			cfg.Storage.SupplyHeightKey,
+			cfg.Storage.InflationKey,
+			cfg.Storage.InflationHeightKey,
+			cfg.Storage.InflationMethodKey,
+			cfg.Storage.APRKey,
+			cfg.Storage.APRHeightKey,
 		}
 
-		formattedSupply, err := formatSupply(supply)
-		if err != nil {
-			badRequest(w, err)
-			return
+		if cfg.Eth.Enabled {
+			keys = append(keys, cfg.Storage.EthSupplyKey, cfg.Storage.EthSupplyHeightKey)
 		}
 
-		supplyHeight, err := storage.GetInt64Value(cfg.Storage.SupplyHeightKey)
+		// Values are read together so a value is never paired with the height of another calculation
+		entries, err := storage.GetEntries(keys...)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
-		inflation, err := storage.GetValue(cfg.Storage.InflationKey)
+		supply, err := getSupplyAtHeight(entries[0], entries[1])
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
-		inflationHeight, err := storage.GetInt64Value(cfg.Storage.InflationHeightKey)
+		inflationHeight, err := strconv.ParseInt(entries[3].Value, 10, 64)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
-		apr, err := storage.GetValue(cfg.Storage.APRKey)
+		aprHeight, err := strconv.ParseInt(entries[6].Value, 10, 64)
 		if err != nil {
-			badRequest(w, err)
-			return
-		}
-
-		aprHeight, err := storage.GetInt64Value(cfg.Storage.APRHeightKey)
-		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
-		setHeaders(w)
+		var ethSupply *valueAtHeight
 
-		if err := json.NewEncoder(w).Encode(statsResponse{
-			Inflation: valueAtHeight{Value: inflation, Height: inflationHeight},
-			APR:       valueAtHeight{Value: apr, Height: aprHeight},
-			Supply:    valueAtHeight{Value: formattedSupply, Height: supplyHeight},
-		}); err != nil {
-			badRequest(w, err)
+		if cfg.Eth.Enabled {
+			ethSupply, err = getSupplyAtHeight(entries[7], entries[8])
+			if err != nil {
+				handleError(w, err)
+				return
+			}
 		}
+
+		writeCachedJSON(w, r, cfg, getLatestUpdate(entries), statsResponse{
+			Inflation: inflationAtHeight{
+				Value:     entries[2].Value,
+				Height:    inflationHeight,
+				Method:    entries[4].Value,
+				UpdatedAt: entries[2].UpdatedAt,
+			},
+			APR:       valueAtHeight{Value: entries[5].Value, Height: aprHeight, UpdatedAt: entries[5].UpdatedAt},
+			Supply:    *supply,
+			EthSupply: ethSupply,
+		})
 	}
 }
 
-func GetSupplyHandler(cfg config.Config, storage keyValueStorage) func(http.ResponseWriter, *http.Request) {
+// Excluded accounts already carry the height they were read at
+func GetExcludedAccountsHandler(cfg config.Config, storage keyValueStorage) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		supply, err := storage.GetValue(cfg.Storage.AllTokensSupplyKey)
+		entries, err := storage.GetEntries(cfg.Storage.ExcludedAccountsKey)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
-		setHeaders(w)
-
-		if _, err := w.Write([]byte(supply)); err != nil {
-			badRequest(w, err)
+		response, err := addMetadata(entries[0].Value, entries[0].UpdatedAt)
+		if err != nil {
+			handleError(w, err)
+			return
 		}
+
+		writeCachedJSON(w, r, cfg, entries[0].UpdatedAt, response)
 	}
 }
 
 func GetAPRHandler(cfg config.Config, storage keyValueStorage) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		apr, err := storage.GetValue(cfg.Storage.APRKey)
+		apr, height, updatedAt, err := getValueAtHeight(storage, cfg.Storage.APRKey, cfg.Storage.APRHeightKey)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
-		setHeaders(w)
-
-		if err := json.NewEncoder(w).Encode(aprResponse{APR: apr}); err != nil {
-			badRequest(w, err)
-		}
+		writeCachedJSON(w, r, cfg, updatedAt, aprResponse{APR: apr, Height: height, UpdatedAt: updatedAt})
 	}
 }
 
 func GetAnnualProvisionsHandler(cfg config.Config, storage keyValueStorage) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		annualProvisions, err := storage.GetValue(cfg.Storage.AnnualProvisionsKey)
+		height, err := getRequestedHeight(r)
 		if err != nil {
 			badRequest(w, err)
 			return
 		}
 
-		setHeaders(w)
+		// Annual provisions depend on the staking pool, which can't be queried at past heights, so only the history is served
+		if height != 0 {
+			point, err := storage.GetHistoryAtHeight(cfg.Storage.AnnualProvisionsKey, height)
+			if err != nil {
+				handleError(w, err)
+				return
+			}
 
-		if err := json.NewEncoder(w).Encode(annualProvisionsResponse{AnnualProvisions: annualProvisions}); err != nil {
-			badRequest(w, err)
+			writeCachedJSON(w, r, cfg, point.Time, annualProvisionsResponse{AnnualProvisions: point.Value, Height: point.Height, UpdatedAt: point.Time})
+			return
 		}
+
+		annualProvisions, height, updatedAt, err := getValueAtHeight(storage, cfg.Storage.AnnualProvisionsKey, cfg.Storage.APRHeightKey)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		writeCachedJSON(w, r, cfg, updatedAt, annualProvisionsResponse{AnnualProvisions: annualProvisions, Height: height, UpdatedAt: updatedAt})
 	}
 }
 
-func GetInflationHandler(cfg config.Config, storage keyValueStorage) func(http.ResponseWriter, *http.Request) {
+func GetInflationHandler(cfg config.Config, storage keyValueStorage, calculator heightCalculator) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		inflation, err := storage.GetValue(cfg.Storage.InflationKey)
+		height, err := getRequestedHeight(r)
 		if err != nil {
 			badRequest(w, err)
 			return
 		}
 
-		setHeaders(w)
+		if height != 0 {
+			point, err := storage.GetHistoryAtHeight(cfg.Storage.InflationKey, height)
+			if err != nil && !isNotCalculated(err) && !isNotRecorded(err) {
+				handleError(w, err)
+				return
+			}
+
+			if err == nil {
+				writeCachedJSON(w, r, cfg, point.Time, inflationResponse{Inflation: point.Value, Height: point.Height, UpdatedAt: point.Time})
+				return
+			}
+
+			// Heights before the recorded history are calculated from the supply at that height
+			inflation, _, calculatedAt, err := calculator.GetInflationAtHeight(height)
+			if err != nil {
+				handleError(w, err)
+				return
+			}
+
+			writeCachedJSON(w, r, cfg, calculatedAt, inflationResponse{Inflation: inflation, Height: height, UpdatedAt: calculatedAt})
+			return
+		}
 
-		if err := json.NewEncoder(w).Encode(inflationResponse{Inflation: inflation}); err != nil {
-			badRequest(w, err)
+		inflation, height, updatedAt, err := getValueAtHeight(storage, cfg.Storage.InflationKey, cfg.Storage.InflationHeightKey)
+		if err != nil {
+			handleError(w, err)
+			return
 		}
+
+		writeCachedJSON(w, r, cfg, updatedAt, inflationResponse{Inflation: inflation, Height: height, UpdatedAt: updatedAt})
 	}
 }
 
+// Params come from the config, so only the max age applies
 func GetParamsHandler(cfg config.Config) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		setHeaders(w)
+		blocksPerYear, err := tasks.GetBlocksPerYear(cfg.InflationGenesis.BlocksPerDay)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
 
-		if err := json.NewEncoder(w).Encode(paramsResponse{
+		setCacheControl(w, cfg)
+
+		writeJSON(w, paramsResponse{
 			Params: params{
 				MintDenom:           cfg.InflationGenesis.MintDenom,
 				InflationRateChange: "0.0",
 				InflationMax:        "0.0",
 				InflationMin:        "0.0",
 				GoalBonded:          "0.0",
-				BlocksPerYear:       cfg.InflationGenesis.BlocksPerDay,
+				BlocksPerYear:       strconv.FormatUint(blocksPerYear, 10),
 			},
-		}); err != nil {
-			badRequest(w, err)
-		}
+		})
 	}
 }
 
 func GetCudosNetworkTotalSupply(cfg config.Config, storage keyValueStorage) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		supply, err := storage.GetValue(cfg.Storage.CudosNetworkTotalSupplyKey)
+		supply, _, updatedAt, err := getValueAtHeight(storage, cfg.Storage.CudosNetworkTotalSupplyKey, cfg.Storage.SupplyHeightKey)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
 		formattedSupply, err := formatSupply(supply)
 		if err != nil {
-			badRequest(w, err)
+			handleError(w, err)
 			return
 		}
 
-		w.Header().Set("Content-Type", "text/html")
-		w.WriteHeader(http.StatusOK)
+		writeCachedText(w, r, cfg, updatedAt, formattedSupply)
+	}
+}
 
-		if _, err := w.Write([]byte(formattedSupply)); err != nil {
-			badRequest(w, err)
+// getValueAtHeight reads a value together with the height it was calculated at and the time of the latest write
+func getValueAtHeight(storage keyValueStorage, valueKey, heightKey string) (string, int64, time.Time, error) {
+	entries, err := storage.GetEntries(valueKey, heightKey)
+	if err != nil {
+		return "", 0, time.Time{}, err
+	}
+
+	height, err := strconv.ParseInt(entries[1].Value, 10, 64)
+	if err != nil {
+		return "", 0, time.Time{}, err
+	}
+
+	return entries[0].Value, height, getLatestUpdate(entries), nil
+}
+
+// Supplies are reported in whole cudos with the height they were calculated at
+func getSupplyAtHeight(supply, height storage.Entry) (*valueAtHeight, error) {
+	formattedSupply, err := formatSupply(supply.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedHeight, err := strconv.ParseInt(height.Value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &valueAtHeight{Value: formattedSupply, Height: parsedHeight, UpdatedAt: supply.UpdatedAt}, nil
+}
+
+// addMetadata adds the write time to a stored JSON object
+func addMetadata(value string, updatedAt time.Time) (map[string]json.RawMessage, error) {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(value), &response); err != nil {
+		return nil, fmt.Errorf("failed to decode stored value: %s", err)
+	}
+
+	updatedAtJSON, err := json.Marshal(updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	response["updated_at"] = updatedAtJSON
+	return response, nil
+}
+
+func GetTasksHandler(runner taskStatusProvider) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, tasksResponse{Tasks: runner.GetStatuses()})
+	}
+}
+
+func GetHealthzHandler() func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, healthResponse{Status: "ok"})
+	}
+}
+
+func GetReadyzHandler(checker readinessChecker) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := checker.CheckReadiness(); err != nil {
+			notReady(w, err, retryAfterSeconds)
 			return
 		}
+
+		writeJSON(w, healthResponse{Status: "ok"})
 	}
 }
 
@@ -232,18 +341,10 @@ func formatSupply(supply string) (string, error) {
 	return formattedSupply.String(), nil
 }
 
-func badRequest(w http.ResponseWriter, err error) {
-	log.Error().Err(err).Send()
-	w.WriteHeader(http.StatusBadRequest)
-}
-
-func setHeaders(w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-}
-
 type aprResponse struct {
-	APR string `json:"apr"`
+	APR       string    `json:"apr"`
+	Height    int64     `json:"height"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type paramsResponse struct {
@@ -260,30 +361,68 @@ type params struct {
 }
 
 type inflationResponse struct {
-	Inflation string `json:"inflation"`
+	Inflation string    `json:"inflation"`
+	Height    int64     `json:"height"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type annualProvisionsResponse struct {
-	AnnualProvisions string `json:"annual_provisions"`
+	AnnualProvisions string    `json:"annual_provisions"`
+	Height           int64     `json:"height"`
+	UpdatedAt        time.Time `json:"updated_at"`
 }
 
 type supplyResponse struct {
-	Supply string `json:"supply"`
+	Supply    string    `json:"supply"`
+	Height    int64     `json:"height"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type statsResponse struct {
-	Inflation valueAtHeight `json:"inflation"`
-	APR       valueAtHeight `json:"apr"`
-	Supply    valueAtHeight `json:"supply"`
+	Inflation inflationAtHeight `json:"inflation"`
+	APR       valueAtHeight     `json:"apr"`
+	Supply    valueAtHeight     `json:"supply"`
+	EthSupply *valueAtHeight    `json:"eth_supply,omitempty"`
 }
 
 type valueAtHeight struct {
-	Value  string `json:"value"`
-	Height int64  `json:"height"`
+	Value     string    `json:"value"`
+	Height    int64     `json:"height"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type inflationAtHeight struct {
+	Value     string    `json:"value"`
+	Height    int64     `json:"height"`
+	Method    string    `json:"method"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type tasksResponse struct {
+	Tasks []tasks.TaskStatus `json:"tasks"`
+}
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+type heightCalculator interface {
+	GetInflationAtHeight(height int64) (string, string, time.Time, error)
+	GetTotalSupplyAtHeight(height int64) (bank.TotalSupplyResponse, time.Time, error)
+}
+
+type readinessChecker interface {
+	CheckReadiness() error
+}
+
+type taskStatusProvider interface {
+	GetStatuses() []tasks.TaskStatus
 }
 
 type keyValueStorage interface {
 	SetValue(key, value string) error
 	GetValue(key string) (string, error)
+	GetEntries(keys ...string) ([]storage.Entry, error)
+	GetHistoryAtHeight(series string, height int64) (storage.HistoryPoint, error)
 	GetInt64Value(key string) (int64, error)
 }
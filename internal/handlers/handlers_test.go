@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/tasks"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/gorilla/mux"
+)
+
+// testCalculator calculates the same values at every height, or fails with err
+type testCalculator struct {
+	calculatedAt time.Time
+	err          error
+}
+
+func (c testCalculator) GetInflationAtHeight(height int64) (string, string, time.Time, error) {
+	return "0.1", "cudos_supply_change_over_30_days", c.calculatedAt, c.err
+}
+
+func (c testCalculator) GetTotalSupplyAtHeight(height int64) (bank.TotalSupplyResponse, time.Time, error) {
+	return bank.TotalSupplyResponse{Supply: sdk.NewCoins(sdk.NewInt64Coin("acudos", 5e18))}, c.calculatedAt, c.err
+}
+
+func TestFailedFormattingIsNotCached(t *testing.T) {
+	cfg := newTestConfig()
+	store := storage.NewStorage()
+
+	if err := store.SetValues(map[string]string{
+		cfg.Storage.SupplyKey:                  "not a number",
+		cfg.Storage.CudosNetworkTotalSupplyKey: "not a number",
+		cfg.Storage.SupplyHeightKey:            "100",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.AppendHistory(100, map[string]string{cfg.Storage.SupplyKey: "not a number"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+	}{
+		{name: "supply text", handler: GetCircSupplyTextHandler(cfg, store), target: "/circulating-supply"},
+		{name: "supply json", handler: GetCircSupplyJSONHandler(cfg, store), target: "/json/circulating-supply"},
+		{name: "total supply", handler: GetCudosNetworkTotalSupply(cfg, store), target: "/cudos-network-total-supply"},
+		{name: "supply history", handler: GetHistoryHandler(cfg, store), target: "/history/supply"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, tt.target, nil), map[string]string{"metric": "supply"})
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+
+			for _, header := range []string{"Cache-Control", "ETag", "Last-Modified"} {
+				if value := w.Header().Get(header); value != "" {
+					t.Errorf("%s = %s on a failed response", header, value)
+				}
+			}
+		})
+	}
+}
+
+func TestOnDemandValuesAreCachedByCalculationTime(t *testing.T) {
+	cfg := newTestConfig()
+	calculatedAt := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	calculator := testCalculator{calculatedAt: calculatedAt}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+	}{
+		{name: "inflation", handler: GetInflationHandler(cfg, storage.NewStorage(), calculator), target: "/cosmos/mint/v1beta1/inflation"},
+		{name: "supply", handler: GetSupplyHandler(cfg, storage.NewStorage(), calculator), target: "/cosmos/bank/v1beta1/supply"},
+		{name: "supply of", handler: GetSupplyOfHandler(cfg, storage.NewStorage(), calculator), target: "/cosmos/bank/v1beta1/supply/by_denom?denom=acudos"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r.Header.Set(blockHeightHeader, "5000")
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body)
+			}
+
+			var res struct {
+				UpdatedAt time.Time `json:"updated_at"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+
+			if !res.UpdatedAt.Equal(calculatedAt) {
+				t.Errorf("updated_at = %s, want %s", res.UpdatedAt, calculatedAt)
+			}
+
+			if got, want := w.Header().Get("Last-Modified"), calculatedAt.Format(http.TimeFormat); got != want {
+				t.Errorf("Last-Modified = %s, want %s", got, want)
+			}
+
+			// The same calculation is served with the same tag
+			r.Header.Set("If-None-Match", w.Header().Get("ETag"))
+			w = httptest.NewRecorder()
+			tt.handler(w, r)
+
+			if w.Code != http.StatusNotModified {
+				t.Errorf("status of a request with the tag = %d, want %d", w.Code, http.StatusNotModified)
+			}
+		})
+	}
+}
+
+func TestPendingCalculationIsRetriedLater(t *testing.T) {
+	cfg := newTestConfig()
+	calculator := testCalculator{err: fmt.Errorf("%w: inflation at height 5000", tasks.ErrCalculationPending)}
+
+	r := httptest.NewRequest(http.MethodGet, "/cosmos/mint/v1beta1/inflation", nil)
+	r.Header.Set(blockHeightHeader, "5000")
+	w := httptest.NewRecorder()
+	GetInflationHandler(cfg, storage.NewStorage(), calculator)(w, r)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+
+	if got := w.Header().Get("Retry-After"); got != "5" {
+		t.Errorf("Retry-After = %s, want 5", got)
+	}
+}
+
+// The gRPC Params query reports the same blocks per year
+func TestParamsReportsBlocksPerYear(t *testing.T) {
+	cfg := newTestConfig()
+
+	w := httptest.NewRecorder()
+	GetParamsHandler(cfg)(w, httptest.NewRequest(http.MethodGet, "/cosmos/mint/v1beta1/params", nil))
+
+	var res paramsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	// 17280 blocks per day in a year of 365.25 days
+	if res.Params.BlocksPerYear != "6311520" {
+		t.Errorf("blocks_per_year = %s, want 6311520", res.Params.BlocksPerYear)
+	}
+}
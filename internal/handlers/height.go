@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Header used by the Cosmos SDK gRPC gateway to query state at a past height
+const blockHeightHeader = "x-cosmos-block-height"
+
+// getRequestedHeight returns the height from the header or the legacy height query, 0 meaning the latest values
+func getRequestedHeight(r *http.Request) (int64, error) {
+	value := r.Header.Get(blockHeightHeader)
+	if value == "" {
+		value = r.URL.Query().Get("height")
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	height, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || height < 0 {
+		return 0, fmt.Errorf("invalid height %s", value)
+	}
+
+	return height, nil
+}
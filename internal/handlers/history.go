@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+func GetHistoryHandler(cfg config.Config, storage historyStorage) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metric := mux.Vars(r)["metric"]
+
+		series, ok := getHistorySeries(cfg)[metric]
+		if !ok {
+			notFound(w, fmt.Errorf("unknown metric %s", metric))
+			return
+		}
+
+		query := r.URL.Query()
+
+		from, err := parseHistoryTime(query.Get("from"), time.Time{})
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+
+		to, err := parseHistoryTime(query.Get("to"), time.Now().UTC())
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+
+		interval, err := parseHistoryInterval(query.Get("interval"))
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+
+		points, err := storage.GetHistory(series.key, from, to)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		// New points are the only writes that change a series
+		var updatedAt time.Time
+		if len(points) > 0 {
+			updatedAt = points[len(points)-1].Time
+		}
+
+		points = downsample(points, interval)
+
+		if series.isSupply {
+			for i := range points {
+				if points[i].Value, err = formatSupply(points[i].Value); err != nil {
+					handleError(w, err)
+					return
+				}
+			}
+		}
+
+		writeCachedJSON(w, r, cfg, updatedAt, historyResponse{Metric: metric, Points: points})
+	}
+}
+
+type historySeries struct {
+	key      string
+	isSupply bool
+}
+
+func getHistorySeries(cfg config.Config) map[string]historySeries {
+	return map[string]historySeries{
+		"apr":               {key: cfg.Storage.APRKey},
+		"annual-provisions": {key: cfg.Storage.AnnualProvisionsKey},
+		"inflation":         {key: cfg.Storage.InflationKey},
+		"supply":            {key: cfg.Storage.SupplyKey, isSupply: true},
+		"total-supply":      {key: cfg.Storage.CudosNetworkTotalSupplyKey, isSupply: true},
+		"eth-supply":        {key: cfg.Storage.EthSupplyKey, isSupply: true},
+	}
+}
+
+// Accepts both unix seconds and RFC3339 timestamps
+func parseHistoryTime(value string, defaultValue time.Time) (time.Time, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %s, expected unix seconds or RFC3339", value)
+	}
+
+	return t, nil
+}
+
+// Accepts Go durations (e.g. 1h30m) and whole days (e.g. 7d)
+func parseHistoryInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	var interval time.Duration
+	var err error
+
+	if days := strings.TrimSuffix(value, "d"); days != value {
+		var n int64
+		n, err = strconv.ParseInt(days, 10, 64)
+		interval = time.Duration(n) * 24 * time.Hour
+	} else {
+		interval, err = time.ParseDuration(value)
+	}
+
+	if err != nil || interval < 0 {
+		return 0, fmt.Errorf("invalid interval %s", value)
+	}
+
+	return interval, nil
+}
+
+// Keeps the latest point of every interval bucket
+func downsample(points []storage.HistoryPoint, interval time.Duration) []storage.HistoryPoint {
+	if interval == 0 {
+		return points
+	}
+
+	result := []storage.HistoryPoint{}
+
+	for _, point := range points {
+		bucket := point.Time.Truncate(interval)
+		if len(result) > 0 && result[len(result)-1].Time.Truncate(interval).Equal(bucket) {
+			result[len(result)-1] = point
+			continue
+		}
+		result = append(result, point)
+	}
+
+	return result
+}
+
+type historyResponse struct {
+	Metric string                 `json:"metric"`
+	Points []storage.HistoryPoint `json:"points"`
+}
+
+type historyStorage interface {
+	GetHistory(series string, from, to time.Time) ([]storage.HistoryPoint, error)
+}
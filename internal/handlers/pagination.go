@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/types/query"
+)
+
+// parsePageRequest reads the pagination query parameters of the Cosmos SDK gRPC gateway
+func parsePageRequest(values url.Values) (*query.PageRequest, error) {
+	req := &query.PageRequest{}
+
+	if key := values.Get("pagination.key"); key != "" {
+		decoded, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			// The gateway accepts keys in both base64 alphabets
+			if decoded, err = base64.URLEncoding.DecodeString(key); err != nil {
+				return nil, fmt.Errorf("invalid pagination.key %s", key)
+			}
+		}
+		req.Key = decoded
+	}
+
+	var err error
+
+	if req.Offset, err = parseUintParam(values, "pagination.offset"); err != nil {
+		return nil, err
+	}
+
+	if req.Limit, err = parseUintParam(values, "pagination.limit"); err != nil {
+		return nil, err
+	}
+
+	if req.CountTotal, err = parseBoolParam(values, "pagination.count_total"); err != nil {
+		return nil, err
+	}
+
+	if req.Reverse, err = parseBoolParam(values, "pagination.reverse"); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+func parseUintParam(values url.Values, name string) (uint64, error) {
+	value := values.Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	parsed, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %s", name, value)
+	}
+
+	return parsed, nil
+}
+
+func parseBoolParam(values url.Values, name string) (bool, error) {
+	value := values.Get(name)
+	if value == "" {
+		return false, nil
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %s", name, value)
+	}
+
+	return parsed, nil
+}
+
+// pageResponseJSON is the pagination of the gateway responses, which encode the total as a string
+type pageResponseJSON struct {
+	NextKey []byte `json:"next_key"`
+	Total   string `json:"total"`
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/tasks"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+)
+
+// Clients are asked to come back after this many seconds when values are not calculated yet
+const retryAfterSeconds = 60
+
+// Calculations at a requested height take seconds, so clients are asked to come back sooner for them
+const calculationRetryAfterSeconds = 5
+
+// errorResponse follows the error body of the Cosmos SDK gRPC gateway, code being a gRPC status code
+type errorResponse struct {
+	Code    codes.Code    `json:"code"`
+	Message string        `json:"message"`
+	Details []interface{} `json:"details"`
+}
+
+// handleError responds with 503 while a value is not calculated yet or its calculation is still running, with 400 for heights the node, the history
+// or the chain don't have and with 500 otherwise
+func handleError(w http.ResponseWriter, err error) {
+	if isNotCalculated(err) {
+		notReady(w, errors.New("value is not calculated yet"), retryAfterSeconds)
+		return
+	}
+
+	if errors.Is(err, tasks.ErrCalculationPending) {
+		notReady(w, err, calculationRetryAfterSeconds)
+		return
+	}
+
+	if isInvalidHeight(err) {
+		badRequest(w, err)
+		return
+	}
+
+	internalError(w, err)
+}
+
+// isNotRecorded reports whether a height is before the first point of a history
+func isNotRecorded(err error) bool {
+	return errors.Is(err, storage.ErrHeightNotRecorded)
+}
+
+// isInvalidHeight reports whether a value can't be served at the requested height
+func isInvalidHeight(err error) bool {
+	var heightErr *rest.HeightError
+
+	return errors.As(err, &heightErr) ||
+		errors.Is(err, storage.ErrHeightNotReached) ||
+		isNotRecorded(err) ||
+		errors.Is(err, tasks.ErrHeightBeforeGenesis) ||
+		errors.Is(err, tasks.ErrChainTooYoung)
+}
+
+// isNotCalculated reports whether a value or history point is missing from the storage
+func isNotCalculated(err error) bool {
+	return errors.Is(err, storage.ErrKeyNotFound)
+}
+
+func badRequest(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusBadRequest, codes.InvalidArgument, err.Error())
+}
+
+func notFound(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusNotFound, codes.NotFound, err.Error())
+}
+
+func notReady(w http.ResponseWriter, err error, retryAfter int) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	writeError(w, http.StatusServiceUnavailable, codes.Unavailable, err.Error())
+}
+
+// Internal error details are only logged
+func internalError(w http.ResponseWriter, err error) {
+	log.Error().Err(err).Send()
+	writeError(w, http.StatusInternalServerError, codes.Internal, "internal error")
+}
+
+// Errors are never cached, whatever caching headers were set before the failure
+func writeError(w http.ResponseWriter, status int, code codes.Code, message string) {
+	for _, header := range []string{"Cache-Control", "ETag", "Last-Modified", "Vary"} {
+		w.Header().Del(header)
+	}
+
+	body, err := json.Marshal(errorResponse{Code: code, Message: message, Details: []interface{}{}})
+	if err != nil {
+		log.Error().Err(err).Send()
+		w.WriteHeader(status)
+		return
+	}
+
+	writeBody(w, status, "application/json", body)
+}
+
+// The response is encoded before anything is written, so an encoding failure can still change the status
+func writeJSON(w http.ResponseWriter, response interface{}) {
+	body, err := json.Marshal(response)
+	if err != nil {
+		internalError(w, err)
+		return
+	}
+
+	writeBody(w, http.StatusOK, "application/json", append(body, '\n'))
+}
+
+func writeText(w http.ResponseWriter, text string) {
+	writeBody(w, http.StatusOK, "text/html", []byte(text))
+}
+
+func writeBody(w http.ResponseWriter, status int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+
+	if _, err := w.Write(body); err != nil {
+		log.Error().Err(err).Msg("failed to write response")
+	}
+}
+
+func GetNotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, codes.NotFound, "not found")
+	})
+}
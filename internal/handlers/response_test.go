@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/tasks"
+	"google.golang.org/grpc/codes"
+)
+
+func TestHandleErrorStatus(t *testing.T) {
+	cfg := newTestConfig()
+
+	tests := []struct {
+		name           string
+		height         string
+		calculatorErr  error
+		wantStatus     int
+		wantCode       codes.Code
+		wantRetryAfter string
+		wantMessage    string
+	}{
+		{
+			name:           "not calculated",
+			wantStatus:     http.StatusServiceUnavailable,
+			wantCode:       codes.Unavailable,
+			wantRetryAfter: "60",
+			wantMessage:    "value is not calculated yet",
+		},
+		{
+			name:           "calculation pending",
+			height:         "5000",
+			calculatorErr:  fmt.Errorf("%w: inflation at height 5000", tasks.ErrCalculationPending),
+			wantStatus:     http.StatusServiceUnavailable,
+			wantCode:       codes.Unavailable,
+			wantRetryAfter: "5",
+		},
+		{
+			name:       "malformed height",
+			height:     "latest",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:          "height the node doesn't have",
+			height:        "5000",
+			calculatorErr: fmt.Errorf("failed to get total supply: %w", &rest.HeightError{Height: 5000, LatestHeight: 4000, Err: rest.ErrHeightUnknown}),
+			wantStatus:    http.StatusBadRequest,
+			wantCode:      codes.InvalidArgument,
+		},
+		{
+			name:          "height before genesis",
+			height:        "5000",
+			calculatorErr: fmt.Errorf("%w: height 5000, genesis height 6000", tasks.ErrHeightBeforeGenesis),
+			wantStatus:    http.StatusBadRequest,
+			wantCode:      codes.InvalidArgument,
+		},
+		{
+			name:          "height not reached",
+			height:        "5000",
+			calculatorErr: fmt.Errorf("%w: height 5000", storage.ErrHeightNotReached),
+			wantStatus:    http.StatusBadRequest,
+			wantCode:      codes.InvalidArgument,
+		},
+		{
+			name:          "other error",
+			height:        "5000",
+			calculatorErr: errors.New("connection refused"),
+			wantStatus:    http.StatusInternalServerError,
+			wantCode:      codes.Internal,
+			wantMessage:   "internal error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/cosmos/mint/v1beta1/inflation", nil)
+			if tt.height != "" {
+				r.Header.Set(blockHeightHeader, tt.height)
+			}
+			w := httptest.NewRecorder()
+			GetInflationHandler(cfg, storage.NewStorage(), testCalculator{err: tt.calculatorErr})(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			if got := w.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+
+			var res errorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Code != tt.wantCode {
+				t.Errorf("code = %s, want %s", res.Code, tt.wantCode)
+			}
+
+			if tt.wantMessage != "" && res.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", res.Message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/erc20/erc20test"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/distribution"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/tasks"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/grpc"
+)
+
+const testHeight = 1000000
+
+type testNodeClient struct{}
+
+func (testNodeClient) LatestHeight() (int64, error) {
+	return testHeight, nil
+}
+
+type testStakingClient struct {
+	stakingtypes.QueryClient
+}
+
+func (testStakingClient) Pool(ctx context.Context, in *stakingtypes.QueryPoolRequest, opts ...grpc.CallOption) (*stakingtypes.QueryPoolResponse, error) {
+	return &stakingtypes.QueryPoolResponse{Pool: stakingtypes.Pool{BondedTokens: sdk.NewInt(1e18)}}, nil
+}
+
+type testBankClient struct{}
+
+func (testBankClient) GetTotalSupply(ctx context.Context, height int64) (bank.TotalSupplyResponse, error) {
+	return bank.TotalSupplyResponse{Supply: sdk.NewCoins(sdk.NewInt64Coin("acudos", 5e18))}, nil
+}
+
+func (testBankClient) GetBalance(ctx context.Context, height int64, address, denom string) (sdk.Coin, error) {
+	return sdk.NewInt64Coin(denom, 1e18), nil
+}
+
+type testDistributionClient struct{}
+
+func (testDistributionClient) GetParams(ctx context.Context) (distribution.ParametersResponse, error) {
+	return distribution.ParametersResponse{CommunityTax: "0.02"}, nil
+}
+
+func newTestConfig() config.Config {
+	var cfg config.Config
+
+	cfg.InflationGenesis.InitialHeight = 1
+	cfg.InflationGenesis.NormTimePassed = "0.53172694105988"
+	cfg.InflationGenesis.BlocksPerDay = "17280"
+	cfg.InflationGenesis.MintDenom = "acudos"
+	cfg.InflationGenesis.GravityAccountAddress = "cudos16n3lc7cywa68mg50qhp847034w88pntq8823tx"
+	cfg.APRGenesis.InitialHeight = 1
+	cfg.APRGenesis.NormTimePassed = "0.53172694105988"
+	cfg.APRGenesis.RealBlocksPerDay = "13824"
+	cfg.APRGenesis.BlocksPerDay = "17280"
+	cfg.APRGenesis.MintDenom = "acudos"
+	cfg.APRGenesis.GravityAccountAddress = cfg.InflationGenesis.GravityAccountAddress
+	cfg.Calculation.InflationSinceDays = 30
+
+	cfg.Storage.APRKey = "apr"
+	cfg.Storage.APRHeightKey = "apr_height"
+	cfg.Storage.APRNormTimeCheckpointKey = "apr_norm_time_checkpoint"
+	cfg.Storage.AnnualProvisionsKey = "annual_provisions"
+	cfg.Storage.InflationKey = "inflation"
+	cfg.Storage.InflationHeightKey = "inflation_height"
+	cfg.Storage.InflationMethodKey = "inflation_method"
+	cfg.Storage.AllTokensSupplyKey = "all_tokens_supply"
+	cfg.Storage.SupplyKey = "supply"
+	cfg.Storage.SupplyHeightKey = "supply_height"
+	cfg.Storage.CudosNetworkTotalSupplyKey = "cudos_network_total_supply"
+	cfg.Storage.EthSupplyKey = "eth_supply"
+	cfg.Storage.EthSupplyHeightKey = "eth_supply_height"
+	cfg.Storage.ExcludedAccountsKey = "excluded_accounts"
+
+	return cfg
+}
+
+func TestStatsReportsEthSupply(t *testing.T) {
+	tenBillion, _ := new(big.Int).SetString("10000000000000000000000000000", 10)
+	token := erc20test.Deploy(t, tenBillion)
+
+	// The account holds 1000 CUDOS at the start of the inflation period and 1234 CUDOS at its end
+	account := common.HexToAddress("0xe4422BCDc20E93014F67b73d4120b878c4246804")
+	token.Transfer(t, account, new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18)))
+	token.Transfer(t, account, new(big.Int).Mul(big.NewInt(234), big.NewInt(1e18)))
+
+	cfg := newTestConfig()
+	cfg.Eth.Enabled = true
+	cfg.Eth.TokenAddress = token.Address.Hex()
+	cfg.Eth.EthAccounts = []string{account.Hex()}
+
+	// Every simulated block stands for the 30 days of eth blocks, so the start of the period is the block before the latest
+	archive := erc20test.Archive{Backend: token.Backend, Stride: 30 * 5760}
+
+	store := storage.NewStorage()
+
+	runner, err := tasks.NewRunner(cfg, testNodeClient{}, testStakingClient{}, testBankClient{}, testDistributionClient{}, archive, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runner.ExecuteTasks(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	GetStatsHandler(cfg, store)(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	var res statsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if res.EthSupply == nil {
+		t.Fatalf("eth_supply is missing from %s", w.Body)
+	}
+
+	// Ten billion minus the balance of the account, in CUDOS
+	if res.EthSupply.Value != "9999998766" {
+		t.Errorf("eth_supply value = %s, want 9999998766", res.EthSupply.Value)
+	}
+
+	if want := int64(token.Backend.Blockchain().CurrentBlock().NumberU64()) * archive.Stride; res.EthSupply.Height != want {
+		t.Errorf("eth_supply height = %d, want %d", res.EthSupply.Height, want)
+	}
+
+	if res.Inflation.Method != "cudos_eth_supply_change_over_30_days" {
+		t.Errorf("inflation method = %s", res.Inflation.Method)
+	}
+
+	// The cudos supply of 5 CUDOS doesn't change, the eth one shrinks by the 234 CUDOS sent within the period.
+	// Reading the start at the latest block would report no inflation at all.
+	startSupply, _ := sdk.NewIntFromString("9999999005000000000000000000")
+	want := sdk.NewInt(-234).Mul(sdk.NewInt(1e18)).ToDec().Quo(startSupply.ToDec()).MulInt64(365).QuoInt64(30)
+
+	inflation, err := sdk.NewDecFromStr(res.Inflation.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !inflation.Equal(want) {
+		t.Errorf("inflation = %s, want %s", inflation, want)
+	}
+}
+
+func TestStatsOmitsEthSupplyWhenDisabled(t *testing.T) {
+	cfg := newTestConfig()
+	store := storage.NewStorage()
+
+	runner, err := tasks.NewRunner(cfg, testNodeClient{}, testStakingClient{}, testBankClient{}, testDistributionClient{}, nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runner.ExecuteTasks(); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	GetStatsHandler(cfg, store)(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	var res map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := res["eth_supply"]; ok {
+		t.Errorf("eth_supply is reported while eth is disabled: %s", w.Body)
+	}
+}
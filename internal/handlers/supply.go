@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/pagination"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/gorilla/mux"
+)
+
+func GetSupplyHandler(cfg config.Config, storage keyValueStorage, calculator heightCalculator) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		height, err := getRequestedHeight(r)
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+
+		pageRequest, err := parsePageRequest(r.URL.Query())
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+
+		supply, err := getRequestedSupply(cfg, storage, calculator, height)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		coins, pageResponse, err := pagination.PaginateCoins(supply.coins, pageRequest)
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+
+		writeCachedJSON(w, r, cfg, supply.updatedAt, totalSupplyResponse{
+			Supply: coins,
+			Pagination: pageResponseJSON{
+				NextKey: pageResponse.NextKey,
+				Total:   strconv.FormatUint(pageResponse.Total, 10),
+			},
+			Height:    supply.height,
+			UpdatedAt: supply.updatedAt,
+		})
+	}
+}
+
+// GetSupplyOfHandler serves both the denom path and the by_denom query, the latter being needed for denoms with slashes
+func GetSupplyOfHandler(cfg config.Config, storage keyValueStorage, calculator heightCalculator) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		denom, ok := mux.Vars(r)["denom"]
+		if !ok {
+			denom = r.URL.Query().Get("denom")
+		}
+
+		if err := sdk.ValidateDenom(denom); err != nil {
+			badRequest(w, err)
+			return
+		}
+
+		height, err := getRequestedHeight(r)
+		if err != nil {
+			badRequest(w, err)
+			return
+		}
+
+		supply, err := getRequestedSupply(cfg, storage, calculator, height)
+		if err != nil {
+			handleError(w, err)
+			return
+		}
+
+		// Like the bank module, a denom without supply is reported with a zero amount
+		writeCachedJSON(w, r, cfg, supply.updatedAt, supplyOfResponse{
+			Amount:    sdk.NewCoin(denom, supply.coins.AmountOf(denom)),
+			Height:    supply.height,
+			UpdatedAt: supply.updatedAt,
+		})
+	}
+}
+
+// getRequestedSupply returns the stored supply of all tokens, or reads it from the node for other heights
+func getRequestedSupply(cfg config.Config, storage keyValueStorage, calculator heightCalculator, height int64) (requestedSupply, error) {
+	value, supplyHeight, updatedAt, err := getValueAtHeight(storage, cfg.Storage.AllTokensSupplyKey, cfg.Storage.SupplyHeightKey)
+	if err != nil && (height == 0 || !isNotCalculated(err)) {
+		return requestedSupply{}, err
+	}
+
+	if err == nil && (height == 0 || height == supplyHeight) {
+		var supply bank.TotalSupplyResponse
+		if err := json.Unmarshal([]byte(value), &supply); err != nil {
+			return requestedSupply{}, fmt.Errorf("failed to decode stored supply: %s", err)
+		}
+
+		return requestedSupply{coins: supply.Supply, height: supplyHeight, updatedAt: updatedAt}, nil
+	}
+
+	// A supply read at a past height never changes, so the time it was read at stays the same for the cached reads
+	supply, calculatedAt, err := calculator.GetTotalSupplyAtHeight(height)
+	if err != nil {
+		return requestedSupply{}, err
+	}
+
+	return requestedSupply{coins: supply.Supply, height: height, updatedAt: calculatedAt}, nil
+}
+
+type requestedSupply struct {
+	coins     sdk.Coins
+	height    int64
+	updatedAt time.Time
+}
+
+type totalSupplyResponse struct {
+	Supply     sdk.Coins        `json:"supply"`
+	Pagination pageResponseJSON `json:"pagination"`
+	Height     int64            `json:"height"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+type supplyOfResponse struct {
+	Amount    sdk.Coin  `json:"amount"`
+	Height    int64     `json:"height"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
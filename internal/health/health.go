@@ -0,0 +1,116 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+)
+
+// Checker decides whether the service is ready to serve its values
+type Checker struct {
+	cfg        config.Config
+	storage    keyValueStorage
+	nodeClient nodeClient
+
+	mu               sync.Mutex
+	lastHeight       int64
+	lastHeightChange time.Time
+}
+
+func NewChecker(cfg config.Config, storage keyValueStorage, nodeClient nodeClient) *Checker {
+	return &Checker{
+		cfg:        cfg,
+		storage:    storage,
+		nodeClient: nodeClient,
+	}
+}
+
+// CheckReadiness returns the reason the service is not ready or nil if it is. The service is ready
+// once every value served by the handlers is calculated, none of them is older than the
+// configured staleness and the node height kept advancing within the configured stall period.
+func (c *Checker) CheckReadiness() error {
+	keys := c.getRequiredKeys()
+
+	updatedAt, err := c.storage.GetUpdatedAt(keys...)
+	if err != nil {
+		return fmt.Errorf("values are not calculated yet: %s", err)
+	}
+
+	if maxStaleness := c.cfg.Health.MaxStaleness; maxStaleness > 0 {
+		for i, key := range keys {
+			if age := time.Since(updatedAt[i]); age > maxStaleness {
+				return fmt.Errorf("%s was last updated %s ago", key, age.Truncate(time.Second))
+			}
+		}
+	}
+
+	if c.cfg.Health.MaxHeightStall > 0 {
+		if err := c.checkHeightAdvancing(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Checker) checkHeightAdvancing() error {
+	if c.nodeClient == nil {
+		return errors.New("node client is null")
+	}
+
+	height, err := c.nodeClient.LastHeight()
+	if err != nil {
+		return fmt.Errorf("failed to get last block height: %s", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	if height > c.lastHeight {
+		c.lastHeight = height
+		c.lastHeightChange = now
+		return nil
+	}
+
+	if stall := now.Sub(c.lastHeightChange); stall > c.cfg.Health.MaxHeightStall {
+		return fmt.Errorf("node height is stuck at %d for %s", height, stall.Truncate(time.Second))
+	}
+
+	return nil
+}
+
+func (c *Checker) getRequiredKeys() []string {
+	keys := []string{
+		c.cfg.Storage.APRKey,
+		c.cfg.Storage.APRHeightKey,
+		c.cfg.Storage.AnnualProvisionsKey,
+		c.cfg.Storage.InflationKey,
+		c.cfg.Storage.InflationHeightKey,
+		c.cfg.Storage.InflationMethodKey,
+		c.cfg.Storage.AllTokensSupplyKey,
+		c.cfg.Storage.SupplyKey,
+		c.cfg.Storage.SupplyHeightKey,
+		c.cfg.Storage.CudosNetworkTotalSupplyKey,
+		c.cfg.Storage.ExcludedAccountsKey,
+	}
+
+	if c.cfg.Eth.Enabled {
+		keys = append(keys, c.cfg.Storage.EthSupplyKey, c.cfg.Storage.EthSupplyHeightKey)
+	}
+
+	return keys
+}
+
+type keyValueStorage interface {
+	GetUpdatedAt(keys ...string) ([]time.Time, error)
+}
+
+// nodeClient returns the height of the last endpoint check, so readiness probes don't check the endpoints themselves
+type nodeClient interface {
+	LastHeight() (int64, error)
+}
@@ -0,0 +1,161 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+)
+
+type testStorage map[string]time.Time
+
+func (s testStorage) GetUpdatedAt(keys ...string) ([]time.Time, error) {
+	updatedAt := make([]time.Time, len(keys))
+	for i, key := range keys {
+		t, ok := s[key]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", key)
+		}
+		updatedAt[i] = t
+	}
+	return updatedAt, nil
+}
+
+type testNodeClient struct {
+	height int64
+	err    error
+}
+
+func (c *testNodeClient) LastHeight() (int64, error) {
+	return c.height, c.err
+}
+
+func newTestConfig() config.Config {
+	var cfg config.Config
+	cfg.Storage.APRKey = "apr"
+	cfg.Storage.APRHeightKey = "apr_height"
+	cfg.Storage.AnnualProvisionsKey = "annual_provisions"
+	cfg.Storage.InflationKey = "inflation"
+	cfg.Storage.InflationHeightKey = "inflation_height"
+	cfg.Storage.InflationMethodKey = "inflation_method"
+	cfg.Storage.AllTokensSupplyKey = "all_tokens_supply"
+	cfg.Storage.SupplyKey = "supply"
+	cfg.Storage.SupplyHeightKey = "supply_height"
+	cfg.Storage.CudosNetworkTotalSupplyKey = "cudos_network_total_supply"
+	cfg.Storage.ExcludedAccountsKey = "excluded_accounts"
+	cfg.Health.MaxStaleness = time.Hour
+	cfg.Health.MaxHeightStall = time.Minute
+	return cfg
+}
+
+// newUpdatedStorage holds every required key updated at the given time
+func newUpdatedStorage(cfg config.Config, updatedAt time.Time) testStorage {
+	store := testStorage{}
+	for _, key := range (&Checker{cfg: cfg}).getRequiredKeys() {
+		store[key] = updatedAt
+	}
+	return store
+}
+
+func TestCheckReadiness(t *testing.T) {
+	cfg := newTestConfig()
+
+	tests := []struct {
+		name       string
+		storage    func() testStorage
+		nodeClient *testNodeClient
+		// lastHeightChange is how long ago the checker last saw the height advance
+		lastHeightChange time.Duration
+		wantErr          string
+	}{
+		{
+			name:       "healthy",
+			storage:    func() testStorage { return newUpdatedStorage(cfg, time.Now()) },
+			nodeClient: &testNodeClient{height: 100},
+		},
+		{
+			name: "missing key",
+			storage: func() testStorage {
+				store := newUpdatedStorage(cfg, time.Now())
+				delete(store, cfg.Storage.SupplyKey)
+				return store
+			},
+			nodeClient: &testNodeClient{height: 100},
+			wantErr:    "values are not calculated yet",
+		},
+		{
+			name: "stale value",
+			storage: func() testStorage {
+				store := newUpdatedStorage(cfg, time.Now())
+				store[cfg.Storage.APRKey] = time.Now().Add(-2 * time.Hour)
+				return store
+			},
+			nodeClient: &testNodeClient{height: 100},
+			wantErr:    "apr was last updated",
+		},
+		{
+			name:             "height advancing",
+			storage:          func() testStorage { return newUpdatedStorage(cfg, time.Now()) },
+			nodeClient:       &testNodeClient{height: 101},
+			lastHeightChange: 2 * time.Minute,
+		},
+		{
+			name:             "height stalled",
+			storage:          func() testStorage { return newUpdatedStorage(cfg, time.Now()) },
+			nodeClient:       &testNodeClient{height: 100},
+			lastHeightChange: 2 * time.Minute,
+			wantErr:          "node height is stuck at 100",
+		},
+		{
+			name:       "height unavailable",
+			storage:    func() testStorage { return newUpdatedStorage(cfg, time.Now()) },
+			nodeClient: &testNodeClient{err: errors.New("no endpoint")},
+			wantErr:    "failed to get last block height",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewChecker(cfg, tt.storage(), tt.nodeClient)
+			if tt.lastHeightChange > 0 {
+				checker.lastHeight = 100
+				checker.lastHeightChange = time.Now().Add(-tt.lastHeightChange)
+			}
+
+			err := checker.CheckReadiness()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckReadinessEthKeys(t *testing.T) {
+	cfg := newTestConfig()
+	store := newUpdatedStorage(cfg, time.Now())
+
+	cfg.Eth.Enabled = true
+	cfg.Storage.EthSupplyKey = "eth_supply"
+	cfg.Storage.EthSupplyHeightKey = "eth_supply_height"
+
+	checker := NewChecker(cfg, store, &testNodeClient{height: 100})
+	if err := checker.CheckReadiness(); err == nil || !strings.Contains(err.Error(), "eth_supply") {
+		t.Fatalf("err = %v, want the missing eth supply to be reported", err)
+	}
+
+	store[cfg.Storage.EthSupplyKey] = time.Now()
+	store[cfg.Storage.EthSupplyHeightKey] = time.Now()
+	if err := checker.CheckReadiness(); err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+}
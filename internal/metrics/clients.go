@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/distribution"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/grpc"
+)
+
+// The clients below wrap the upstream clients and observe the latency and errors of every call
+
+type instrumentedBankClient struct {
+	client bankQueryClient
+}
+
+func InstrumentBankClient(client bankQueryClient) *instrumentedBankClient {
+	return &instrumentedBankClient{client: client}
+}
+
+func (c *instrumentedBankClient) GetTotalSupply(ctx context.Context, height int64) (bank.TotalSupplyResponse, error) {
+	started := time.Now()
+	res, err := c.client.GetTotalSupply(ctx, height)
+	ObserveUpstreamCall("bank", "GetTotalSupply", started, err)
+	return res, err
+}
+
+func (c *instrumentedBankClient) GetBalance(ctx context.Context, height int64, address, denom string) (sdk.Coin, error) {
+	started := time.Now()
+	res, err := c.client.GetBalance(ctx, height, address, denom)
+	ObserveUpstreamCall("bank", "GetBalance", started, err)
+	return res, err
+}
+
+type instrumentedDistributionClient struct {
+	client distributionQueryClient
+}
+
+func InstrumentDistributionClient(client distributionQueryClient) *instrumentedDistributionClient {
+	return &instrumentedDistributionClient{client: client}
+}
+
+func (c *instrumentedDistributionClient) GetParams(ctx context.Context) (distribution.ParametersResponse, error) {
+	started := time.Now()
+	res, err := c.client.GetParams(ctx)
+	ObserveUpstreamCall("distribution", "GetParams", started, err)
+	return res, err
+}
+
+// Only the staking queries used by the tasks are instrumented, the rest are passed through
+type instrumentedStakingClient struct {
+	stakingtypes.QueryClient
+}
+
+func InstrumentStakingClient(client stakingtypes.QueryClient) *instrumentedStakingClient {
+	return &instrumentedStakingClient{QueryClient: client}
+}
+
+func (c *instrumentedStakingClient) Pool(ctx context.Context, in *stakingtypes.QueryPoolRequest, opts ...grpc.CallOption) (*stakingtypes.QueryPoolResponse, error) {
+	started := time.Now()
+	res, err := c.QueryClient.Pool(ctx, in, opts...)
+	ObserveUpstreamCall("staking", "Pool", started, err)
+	return res, err
+}
+
+type instrumentedEthClient struct {
+	client ethClient
+}
+
+func InstrumentEthClient(client ethClient) *instrumentedEthClient {
+	return &instrumentedEthClient{client: client}
+}
+
+func (c *instrumentedEthClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	started := time.Now()
+	res, err := c.client.CodeAt(ctx, contract, blockNumber)
+	ObserveUpstreamCall("eth", "CodeAt", started, err)
+	return res, err
+}
+
+func (c *instrumentedEthClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	started := time.Now()
+	res, err := c.client.CallContract(ctx, call, blockNumber)
+	ObserveUpstreamCall("eth", "CallContract", started, err)
+	return res, err
+}
+
+func (c *instrumentedEthClient) HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error) {
+	started := time.Now()
+	res, err := c.client.HeaderByNumber(ctx, number)
+	ObserveUpstreamCall("eth", "HeaderByNumber", started, err)
+	return res, err
+}
+
+type bankQueryClient interface {
+	GetTotalSupply(ctx context.Context, height int64) (bank.TotalSupplyResponse, error)
+	GetBalance(ctx context.Context, height int64, address, denom string) (sdk.Coin, error)
+}
+
+type distributionQueryClient interface {
+	GetParams(ctx context.Context) (distribution.ParametersResponse, error)
+}
+
+type ethClient interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error)
+}
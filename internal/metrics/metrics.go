@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "cudos_stats"
+
+var (
+	taskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "task_duration_seconds",
+		Help:      "Duration of task runs including retries.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600},
+	}, []string{"task"})
+
+	taskFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "task_failures_total",
+		Help:      "Number of task runs that failed after all retries.",
+	}, []string{"task"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of calls to the Cudos and Ethereum nodes.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"client", "method"})
+
+	upstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_errors_total",
+		Help:      "Number of failed calls to the Cudos and Ethereum nodes.",
+	}, []string{"client", "method"})
+
+	endpointUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "upstream_endpoint_up",
+		Help:      "Whether a Cudos node endpoint passed its last health check.",
+	}, []string{"pool", "endpoint"})
+
+	endpointHeight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "upstream_endpoint_height",
+		Help:      "Latest height reported by a Cudos node endpoint.",
+	}, []string{"pool", "endpoint"})
+
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests served per route and status code.",
+	}, []string{"route", "code"})
+
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "Number of gRPC requests served per method and status code.",
+	}, []string{"method", "code"})
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+func ObserveTask(task string, started time.Time, err error) {
+	taskDuration.WithLabelValues(task).Observe(time.Since(started).Seconds())
+	if err != nil {
+		taskFailures.WithLabelValues(task).Inc()
+	}
+}
+
+func ObserveUpstreamCall(client, method string, started time.Time, err error) {
+	upstreamDuration.WithLabelValues(client, method).Observe(time.Since(started).Seconds())
+	if err != nil {
+		upstreamErrors.WithLabelValues(client, method).Inc()
+	}
+}
+
+func ObserveEndpoint(pool, endpoint string, healthy bool, height int64) {
+	up := 0.0
+	if healthy {
+		up = 1
+	}
+	endpointUp.WithLabelValues(pool, endpoint).Set(up)
+	endpointHeight.WithLabelValues(pool, endpoint).Set(float64(height))
+}
+
+// HTTPMiddleware counts the requests by their route template, so path variables don't create new series
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		httpRequests.WithLabelValues(route, strconv.Itoa(recorder.status)).Inc()
+	})
+}
+
+func GRPCUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	grpcRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+	return resp, err
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"math/big"
+	"strconv"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+// storageCollector reads the calculated values from the storage on every scrape,
+// so the gauges always match what the HTTP handlers are serving.
+type storageCollector struct {
+	storage keyValueStorage
+	gauges  []storageGauge
+}
+
+type storageGauge struct {
+	desc *prometheus.Desc
+	key  string
+	// Supplies are stored in acudos and exported in cudos
+	isSupply bool
+}
+
+func RegisterStorageCollector(cfg config.Config, storage keyValueStorage) error {
+	newGauge := func(name, help, key string, isSupply bool) storageGauge {
+		return storageGauge{
+			desc:     prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, nil, nil),
+			key:      key,
+			isSupply: isSupply,
+		}
+	}
+
+	return prometheus.Register(&storageCollector{
+		storage: storage,
+		gauges: []storageGauge{
+			newGauge("apr", "Staking APR.", cfg.Storage.APRKey, false),
+			newGauge("inflation", "Annual inflation.", cfg.Storage.InflationKey, false),
+			newGauge("annual_provisions", "Annual provisions in acudos.", cfg.Storage.AnnualProvisionsKey, false),
+			newGauge("circulating_supply", "Circulating supply in cudos.", cfg.Storage.SupplyKey, true),
+			newGauge("total_supply", "Cudos network total supply in cudos.", cfg.Storage.CudosNetworkTotalSupplyKey, true),
+		},
+	})
+}
+
+func (c *storageCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, gauge := range c.gauges {
+		ch <- gauge.desc
+	}
+}
+
+// Values that are not calculated yet are left out
+func (c *storageCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, gauge := range c.gauges {
+		value, err := c.storage.GetValue(gauge.key)
+		if err != nil {
+			continue
+		}
+
+		floatValue, err := parseFloat(value, gauge.isSupply)
+		if err != nil {
+			log.Warn().Err(err).Msgf("failed to export %s", gauge.key)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(gauge.desc, prometheus.GaugeValue, floatValue)
+	}
+}
+
+func parseFloat(value string, isSupply bool) (float64, error) {
+	if !isSupply {
+		return strconv.ParseFloat(value, 64)
+	}
+
+	supply, ok := new(big.Float).SetString(value)
+	if !ok {
+		return 0, strconv.ErrSyntax
+	}
+
+	floatValue, _ := supply.Quo(supply, big.NewFloat(1e18)).Float64()
+	return floatValue, nil
+}
+
+type keyValueStorage interface {
+	GetValue(key string) (string, error)
+}
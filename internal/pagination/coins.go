@@ -0,0 +1,67 @@
+package pagination
+
+import (
+	"errors"
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+)
+
+// ErrKeyAndOffset is returned for requests the Cosmos SDK rejects as well, every other request yields a page
+var ErrKeyAndOffset = errors.New("invalid request, either offset or key is expected, got both")
+
+// PaginateCoins pages through coins the way the Cosmos SDK pages through the bank store, the denom being the key.
+// Like in the SDK a missing limit selects the default limit and counts the total, and the total is not counted
+// for requests continuing from a key.
+func PaginateCoins(coins sdk.Coins, req *query.PageRequest) (sdk.Coins, *query.PageResponse, error) {
+	if req == nil {
+		req = &query.PageRequest{}
+	}
+
+	if len(req.Key) != 0 && req.Offset > 0 {
+		return nil, nil, ErrKeyAndOffset
+	}
+
+	limit, countTotal := req.Limit, req.CountTotal
+	if limit == 0 {
+		limit, countTotal = query.DefaultLimit, true
+	}
+
+	sorted := make(sdk.Coins, len(coins))
+	copy(sorted, coins)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if req.Reverse {
+			return sorted[i].Denom > sorted[j].Denom
+		}
+		return sorted[i].Denom < sorted[j].Denom
+	})
+
+	start := uint64(len(sorted))
+	if len(req.Key) != 0 {
+		key := string(req.Key)
+		for i, coin := range sorted {
+			if (!req.Reverse && coin.Denom >= key) || (req.Reverse && coin.Denom <= key) {
+				start = uint64(i)
+				break
+			}
+		}
+	} else if req.Offset < start {
+		start = req.Offset
+	}
+
+	end := uint64(len(sorted))
+	if limit < end-start {
+		end = start + limit
+	}
+
+	res := &query.PageResponse{}
+	if end < uint64(len(sorted)) {
+		res.NextKey = []byte(sorted[end].Denom)
+	}
+	if countTotal && len(req.Key) == 0 {
+		res.Total = uint64(len(sorted))
+	}
+
+	return sorted[start:end], res, nil
+}
@@ -0,0 +1,157 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/query"
+)
+
+func TestPaginateCoins(t *testing.T) {
+	// Not sorted on purpose, the pages follow the denoms like the bank store
+	coins := sdk.Coins{
+		sdk.NewInt64Coin("ctoken", 3),
+		sdk.NewInt64Coin("acudos", 1),
+		sdk.NewInt64Coin("dtoken", 4),
+		sdk.NewInt64Coin("btoken", 2),
+	}
+
+	tests := []struct {
+		name        string
+		req         *query.PageRequest
+		wantDenoms  []string
+		wantNextKey string
+		wantTotal   uint64
+		wantErr     error
+	}{
+		{
+			name:       "no request counts the total",
+			req:        nil,
+			wantDenoms: []string{"acudos", "btoken", "ctoken", "dtoken"},
+			wantTotal:  4,
+		},
+		{
+			name:       "no limit counts the total",
+			req:        &query.PageRequest{},
+			wantDenoms: []string{"acudos", "btoken", "ctoken", "dtoken"},
+			wantTotal:  4,
+		},
+		{
+			name:        "limit",
+			req:         &query.PageRequest{Limit: 2},
+			wantDenoms:  []string{"acudos", "btoken"},
+			wantNextKey: "ctoken",
+		},
+		{
+			name:        "limit with count total",
+			req:         &query.PageRequest{Limit: 2, CountTotal: true},
+			wantDenoms:  []string{"acudos", "btoken"},
+			wantNextKey: "ctoken",
+			wantTotal:   4,
+		},
+		{
+			name:       "limit past the end",
+			req:        &query.PageRequest{Limit: 10},
+			wantDenoms: []string{"acudos", "btoken", "ctoken", "dtoken"},
+		},
+		{
+			name:        "offset",
+			req:         &query.PageRequest{Offset: 1, Limit: 2, CountTotal: true},
+			wantDenoms:  []string{"btoken", "ctoken"},
+			wantNextKey: "dtoken",
+			wantTotal:   4,
+		},
+		{
+			name:       "offset past the end",
+			req:        &query.PageRequest{Offset: 10, Limit: 2},
+			wantDenoms: []string{},
+		},
+		{
+			name:        "key",
+			req:         &query.PageRequest{Key: []byte("btoken"), Limit: 2},
+			wantDenoms:  []string{"btoken", "ctoken"},
+			wantNextKey: "dtoken",
+		},
+		{
+			name:       "key does not count the total",
+			req:        &query.PageRequest{Key: []byte("ctoken"), Limit: 2, CountTotal: true},
+			wantDenoms: []string{"ctoken", "dtoken"},
+		},
+		{
+			name:       "key between denoms",
+			req:        &query.PageRequest{Key: []byte("bz"), Limit: 5},
+			wantDenoms: []string{"ctoken", "dtoken"},
+		},
+		{
+			name:       "key past the end",
+			req:        &query.PageRequest{Key: []byte("z"), Limit: 2},
+			wantDenoms: []string{},
+		},
+		{
+			name:        "reverse",
+			req:         &query.PageRequest{Limit: 2, Reverse: true, CountTotal: true},
+			wantDenoms:  []string{"dtoken", "ctoken"},
+			wantNextKey: "btoken",
+			wantTotal:   4,
+		},
+		{
+			name:       "reverse with key",
+			req:        &query.PageRequest{Key: []byte("btoken"), Limit: 2, Reverse: true},
+			wantDenoms: []string{"btoken", "acudos"},
+		},
+		{
+			name:        "reverse with offset",
+			req:         &query.PageRequest{Offset: 1, Limit: 1, Reverse: true},
+			wantDenoms:  []string{"ctoken"},
+			wantNextKey: "btoken",
+		},
+		{
+			name:    "key and offset",
+			req:     &query.PageRequest{Key: []byte("btoken"), Offset: 1},
+			wantErr: ErrKeyAndOffset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, res, err := PaginateCoins(coins, tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			denoms := []string{}
+			for _, coin := range page {
+				denoms = append(denoms, coin.Denom)
+			}
+
+			if len(denoms) != len(tt.wantDenoms) {
+				t.Fatalf("denoms = %v, want %v", denoms, tt.wantDenoms)
+			}
+			for i := range denoms {
+				if denoms[i] != tt.wantDenoms[i] {
+					t.Fatalf("denoms = %v, want %v", denoms, tt.wantDenoms)
+				}
+			}
+
+			if string(res.NextKey) != tt.wantNextKey {
+				t.Errorf("next key = %q, want %q", res.NextKey, tt.wantNextKey)
+			}
+
+			if res.Total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", res.Total, tt.wantTotal)
+			}
+		})
+	}
+
+	if coins[0].Denom != "ctoken" {
+		t.Errorf("coins were sorted in place: %v", coins)
+	}
+}
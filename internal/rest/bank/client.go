@@ -1,68 +1,93 @@
 package bank
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
 
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
 	sdkTypes "github.com/cosmos/cosmos-sdk/types"
 )
 
+// Used when no page limit is configured
+const defaultMaxPages = 100
+
+// Client reads the bank module state of a Cudos node at a given height
+type Client interface {
+	GetTotalSupply(ctx context.Context, height int64) (TotalSupplyResponse, error)
+	GetBalance(ctx context.Context, height int64, address, denom string) (sdkTypes.Coin, error)
+}
+
 type bankRESTClient struct {
-	Url string
+	Client   *http.Client
+	Url      string
+	MaxPages int
 }
 
-func NewRestClient(url string) *bankRESTClient {
-	return &bankRESTClient{Url: url}
+func NewRestClient(client *http.Client, url string, maxPages int) *bankRESTClient {
+	return &bankRESTClient{Client: client, Url: url, MaxPages: maxPages}
 }
 
 func (brc bankRESTClient) GetTotalSupply(ctx context.Context, height int64) (TotalSupplyResponse, error) {
-	respStr, err := brc.get(ctx, "/bank/total", height)
-	if err != nil {
-		return TotalSupplyResponse{}, err
-	}
+	return GetAllPages(brc.MaxPages, func(key []byte) (TotalSupplyResponse, error) {
+		respStr, err := brc.get(ctx, "/bank/total", height, getPageParams(key))
+		if err != nil {
+			return TotalSupplyResponse{}, err
+		}
 
-	var res totalSupplyResult
-	if err := json.Unmarshal([]byte(respStr), &res); err != nil {
-		return TotalSupplyResponse{}, err
-	}
+		var res totalSupplyResult
+		if err := json.Unmarshal([]byte(respStr), &res); err != nil {
+			return TotalSupplyResponse{}, rest.InvalidResponse("%s", err)
+		}
 
-	return res.Result, nil
+		if res.Result == nil {
+			return TotalSupplyResponse{}, rest.InvalidResponse("total supply without result")
+		}
+
+		return *res.Result, nil
+	})
 }
 
 func (brc bankRESTClient) GetBalance(ctx context.Context, height int64, address, denom string) (sdkTypes.Coin, error) {
-	respStr, err := brc.get(ctx, fmt.Sprintf("/bank/balances/%s", address), height)
+	respStr, err := brc.get(ctx, fmt.Sprintf("/bank/balances/%s", address), height, url.Values{})
 	if err != nil {
 		return sdkTypes.Coin{}, err
 	}
 
 	var res balanceResponse
 	if err := json.Unmarshal([]byte(respStr), &res); err != nil {
-		return sdkTypes.Coin{}, err
+		return sdkTypes.Coin{}, rest.InvalidResponse("%s", err)
 	}
 
-	if len(res.Result) == 0 {
-		return sdkTypes.Coin{}, nil
+	if res.Result == nil {
+		return sdkTypes.Coin{}, rest.InvalidResponse("balance of %s without result", address)
 	}
 
-	for _, balance := range res.Result {
+	for _, balance := range *res.Result {
 		if balance.Denom == denom {
 			return balance, nil
 		}
 	}
 
-	return sdkTypes.Coin{}, fmt.Errorf("denom %s not found in %+v", denom, res)
+	// Like the gateway and gRPC clients accounts without a balance of the denom are returned with a zero amount
+	return sdkTypes.NewCoin(denom, sdkTypes.ZeroInt()), nil
 }
 
-func (brc bankRESTClient) get(ctx context.Context, uri string, height int64) (string, error) {
-	getReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s?height=%d", brc.Url, uri, height), nil)
+func (brc bankRESTClient) get(ctx context.Context, uri string, height int64, params url.Values) (string, error) {
+	params.Set("height", strconv.FormatInt(height, 10))
+
+	getReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s?%s", brc.Url, uri, params.Encode()), nil)
 	if err != nil {
 		return "", err
 	}
 
-	resp, err := http.DefaultClient.Do(getReq)
+	resp, err := brc.Client.Do(getReq)
 	if err != nil {
 		return "", err
 	}
@@ -73,15 +98,65 @@ func (brc bankRESTClient) get(ctx context.Context, uri string, height int64) (st
 		return "", err
 	}
 
+	if err := rest.CheckResponse(resp.StatusCode, body); err != nil {
+		return "", err
+	}
+
 	return string(body), nil
 }
 
+// GetAllPages follows next_key until the supply of every denom is read, the first page being requested with a nil key
+func GetAllPages(maxPages int, getPage func(key []byte) (TotalSupplyResponse, error)) (TotalSupplyResponse, error) {
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	}
+
+	var supply sdkTypes.Coins
+	var key []byte
+
+	for page := 0; page < maxPages; page++ {
+		res, err := getPage(key)
+		if err != nil {
+			return TotalSupplyResponse{}, err
+		}
+
+		nextKey := res.Pagination.NextKey
+
+		// Nodes that ignore the key keep returning the first page, which would otherwise be counted again
+		if key != nil && bytes.Equal(nextKey, key) {
+			return TotalSupplyResponse{}, fmt.Errorf("total supply pagination did not advance past key %s", key)
+		}
+
+		supply = append(supply, res.Supply...)
+
+		if len(nextKey) == 0 {
+			return TotalSupplyResponse{
+				Supply:     supply.Sort(),
+				Pagination: PageResponse{Total: strconv.Itoa(len(supply))},
+			}, nil
+		}
+
+		key = nextKey
+	}
+
+	return TotalSupplyResponse{}, fmt.Errorf("total supply has more than %d pages", maxPages)
+}
+
+func getPageParams(key []byte) url.Values {
+	params := url.Values{}
+	if key != nil {
+		params.Set("pagination.key", base64.StdEncoding.EncodeToString(key))
+	}
+	return params
+}
+
+// Results are pointers so a response without them is told apart from an empty one
 type balanceResponse struct {
-	Result sdkTypes.Coins `json:"result"`
+	Result *sdkTypes.Coins `json:"result"`
 }
 
 type totalSupplyResult struct {
-	Result TotalSupplyResponse `json:"result"`
+	Result *TotalSupplyResponse `json:"result"`
 }
 
 type TotalSupplyResponse struct {
@@ -0,0 +1,157 @@
+package bank
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	sdkTypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestRESTClientGetBalance(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       sdkTypes.Coin
+		wantErr    bool
+	}{
+		{name: "denom held", statusCode: http.StatusOK, body: `{"height":"100","result":[{"denom":"acudos","amount":"42"},{"denom":"ibc/atom","amount":"7"}]}`, want: sdkTypes.NewInt64Coin("acudos", 42)},
+		{name: "only other denoms", statusCode: http.StatusOK, body: `{"height":"100","result":[{"denom":"ibc/atom","amount":"7"}]}`, want: sdkTypes.NewInt64Coin("acudos", 0)},
+		{name: "no balance", statusCode: http.StatusOK, body: `{"height":"100","result":[]}`, want: sdkTypes.NewInt64Coin("acudos", 0)},
+		{name: "without result", statusCode: http.StatusOK, body: `{"height":"100"}`, wantErr: true},
+		{name: "failed request", statusCode: http.StatusInternalServerError, body: `{"error":"internal"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/bank/balances/cudos1account" || r.URL.Query().Get("height") != "100" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			balance, err := NewRestClient(server.Client(), server.URL, 0).GetBalance(context.Background(), 100, "cudos1account", "acudos")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && (balance.Denom != tt.want.Denom || !balance.Amount.Equal(tt.want.Amount)) {
+				t.Errorf("balance = %s, want %s", balance, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxPages int
+		// pages maps the base64 page key, empty for the first page, to the response
+		pages      map[string]string
+		wantDenoms []string
+		wantCalls  int
+		wantErr    bool
+	}{
+		{
+			name:     "single page",
+			maxPages: 5,
+			pages: map[string]string{
+				"": `{"result":{"supply":[{"denom":"acudos","amount":"10"}],"pagination":{}}}`,
+			},
+			wantDenoms: []string{"acudos"},
+			wantCalls:  1,
+		},
+		{
+			name:     "pages are merged",
+			maxPages: 5,
+			pages: map[string]string{
+				"":         `{"result":{"supply":[{"denom":"ctoken","amount":"3"}],"pagination":{"next_key":"YnRva2Vu"}}}`,
+				"YnRva2Vu": `{"result":{"supply":[{"denom":"btoken","amount":"2"}],"pagination":{"next_key":"YWN1ZG9z"}}}`,
+				"YWN1ZG9z": `{"result":{"supply":[{"denom":"acudos","amount":"1"}],"pagination":{}}}`,
+			},
+			wantDenoms: []string{"acudos", "btoken", "ctoken"},
+			wantCalls:  3,
+		},
+		{
+			name:     "more pages than max pages",
+			maxPages: 2,
+			pages: map[string]string{
+				"":         `{"result":{"supply":[{"denom":"ctoken","amount":"3"}],"pagination":{"next_key":"YnRva2Vu"}}}`,
+				"YnRva2Vu": `{"result":{"supply":[{"denom":"btoken","amount":"2"}],"pagination":{"next_key":"YWN1ZG9z"}}}`,
+				"YWN1ZG9z": `{"result":{"supply":[{"denom":"acudos","amount":"1"}],"pagination":{}}}`,
+			},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+		{
+			name:     "next key does not advance",
+			maxPages: 5,
+			pages: map[string]string{
+				"":         `{"result":{"supply":[{"denom":"ctoken","amount":"3"}],"pagination":{"next_key":"YnRva2Vu"}}}`,
+				"YnRva2Vu": `{"result":{"supply":[{"denom":"btoken","amount":"2"}],"pagination":{"next_key":"YnRva2Vu"}}}`,
+			},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+		{
+			name:     "failed page",
+			maxPages: 5,
+			pages: map[string]string{
+				"": `{"result":{"supply":[{"denom":"ctoken","amount":"3"}],"pagination":{"next_key":"YnRva2Vu"}}}`,
+			},
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				page, ok := tt.pages[r.URL.Query().Get("pagination.key")]
+				if !ok {
+					w.WriteHeader(http.StatusInternalServerError)
+					w.Write([]byte(`{"error":"unknown page"}`))
+					return
+				}
+				w.Write([]byte(page))
+			}))
+			defer server.Close()
+
+			supply, err := NewRestClient(server.Client(), server.URL, tt.maxPages).GetTotalSupply(context.Background(), 100)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("pages requested %d times, want %d", calls, tt.wantCalls)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			denoms := []string{}
+			for _, coin := range supply.Supply {
+				denoms = append(denoms, coin.Denom)
+			}
+
+			if !reflect.DeepEqual(denoms, tt.wantDenoms) {
+				t.Errorf("denoms = %v, want %v", denoms, tt.wantDenoms)
+			}
+
+			if supply.Pagination.Total != strconv.Itoa(len(tt.wantDenoms)) {
+				t.Errorf("total = %s, want %d", supply.Pagination.Total, len(tt.wantDenoms))
+			}
+		})
+	}
+}
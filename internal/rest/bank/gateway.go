@@ -0,0 +1,102 @@
+package bank
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+	sdkTypes "github.com/cosmos/cosmos-sdk/types"
+)
+
+// gatewayClient queries the gRPC gateway endpoints that replace the legacy endpoints in newer Cosmos SDK versions
+type gatewayClient struct {
+	Client   *http.Client
+	Url      string
+	MaxPages int
+}
+
+func NewGatewayClient(client *http.Client, url string, maxPages int) *gatewayClient {
+	return &gatewayClient{Client: client, Url: url, MaxPages: maxPages}
+}
+
+func (c gatewayClient) GetTotalSupply(ctx context.Context, height int64) (TotalSupplyResponse, error) {
+	return GetAllPages(c.MaxPages, func(key []byte) (TotalSupplyResponse, error) {
+		respStr, err := c.get(ctx, "/cosmos/bank/v1beta1/supply", height, getPageParams(key))
+		if err != nil {
+			return TotalSupplyResponse{}, err
+		}
+
+		var res gatewayTotalSupplyResponse
+		if err := json.Unmarshal([]byte(respStr), &res); err != nil {
+			return TotalSupplyResponse{}, rest.InvalidResponse("%s", err)
+		}
+
+		if res.Supply == nil {
+			return TotalSupplyResponse{}, rest.InvalidResponse("total supply without supply")
+		}
+
+		return TotalSupplyResponse{Supply: *res.Supply, Pagination: res.Pagination}, nil
+	})
+}
+
+// Accounts without a balance of the denom are returned with a zero amount
+func (c gatewayClient) GetBalance(ctx context.Context, height int64, address, denom string) (sdkTypes.Coin, error) {
+	respStr, err := c.get(ctx, fmt.Sprintf("/cosmos/bank/v1beta1/balances/%s/by_denom", address), height, url.Values{"denom": {denom}})
+	if err != nil {
+		return sdkTypes.Coin{}, err
+	}
+
+	var res gatewayBalanceResponse
+	if err := json.Unmarshal([]byte(respStr), &res); err != nil {
+		return sdkTypes.Coin{}, rest.InvalidResponse("%s", err)
+	}
+
+	if res.Balance == nil {
+		return sdkTypes.Coin{}, rest.InvalidResponse("balance of %s without balance", address)
+	}
+
+	return *res.Balance, nil
+}
+
+func (c gatewayClient) get(ctx context.Context, uri string, height int64, params url.Values) (string, error) {
+	getReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s?%s", c.Url, uri, params.Encode()), nil)
+	if err != nil {
+		return "", err
+	}
+
+	// Without the header the latest height is queried, the same as height 0 of the legacy endpoints
+	if height > 0 {
+		getReq.Header.Set(rest.BlockHeightHeader, strconv.FormatInt(height, 10))
+	}
+
+	resp, err := c.Client.Do(getReq)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if err := rest.CheckResponse(resp.StatusCode, body); err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+type gatewayTotalSupplyResponse struct {
+	Supply     *sdkTypes.Coins `json:"supply"`
+	Pagination PageResponse    `json:"pagination"`
+}
+
+type gatewayBalanceResponse struct {
+	Balance *sdkTypes.Coin `json:"balance"`
+}
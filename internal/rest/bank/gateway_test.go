@@ -0,0 +1,142 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+)
+
+const prunedHeightMessage = "failed to load state at height 100; version does not exist (latest height: 5000)"
+
+func TestGatewayClientHeightHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		height     int64
+		wantHeader string
+	}{
+		{name: "past height", height: 100, wantHeader: "100"},
+		{name: "latest height", height: 0},
+		{name: "negative height", height: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				headers = append(headers, r.Header.Clone())
+
+				if r.URL.Path == "/cosmos/bank/v1beta1/supply" {
+					w.Write([]byte(`{"supply":[{"denom":"acudos","amount":"10"}],"pagination":{"next_key":null,"total":"1"}}`))
+					return
+				}
+				w.Write([]byte(`{"balance":{"denom":"acudos","amount":"5"}}`))
+			}))
+			defer server.Close()
+
+			client := NewGatewayClient(server.Client(), server.URL, 0)
+
+			if _, err := client.GetTotalSupply(context.Background(), tt.height); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.GetBalance(context.Background(), tt.height, "cudos1account", "acudos"); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, header := range headers {
+				values := header.Values(rest.BlockHeightHeader)
+				if tt.wantHeader == "" && len(values) != 0 {
+					t.Errorf("%s = %v, want no header", rest.BlockHeightHeader, values)
+				}
+				if tt.wantHeader != "" && (len(values) != 1 || values[0] != tt.wantHeader) {
+					t.Errorf("%s = %v, want %s", rest.BlockHeightHeader, values, tt.wantHeader)
+				}
+			}
+		})
+	}
+}
+
+func TestGatewayClientGetTotalSupply(t *testing.T) {
+	tests := []struct {
+		name        string
+		pages       map[string]string
+		statusCode  int
+		wantDenoms  []string
+		wantHeight  error
+		wantStatus  int
+		wantInvalid bool
+	}{
+		{
+			name: "pages are merged",
+			pages: map[string]string{
+				"":         `{"supply":[{"denom":"btoken","amount":"2"}],"pagination":{"next_key":"YWN1ZG9z","total":"2"}}`,
+				"YWN1ZG9z": `{"supply":[{"denom":"acudos","amount":"1"}],"pagination":{"next_key":null,"total":"0"}}`,
+			},
+			statusCode: http.StatusOK,
+			wantDenoms: []string{"acudos", "btoken"},
+		},
+		{
+			name:       "pruned height",
+			pages:      map[string]string{"": `{"code":3,"message":"` + prunedHeightMessage + `: invalid request","details":[]}`},
+			statusCode: http.StatusBadRequest,
+			wantHeight: rest.ErrHeightPruned,
+		},
+		{
+			name:       "other error",
+			pages:      map[string]string{"": `{"code":13,"message":"internal","details":[]}`},
+			statusCode: http.StatusInternalServerError,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:        "without supply",
+			pages:       map[string]string{"": `{"pagination":{}}`},
+			statusCode:  http.StatusOK,
+			wantInvalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.pages[r.URL.Query().Get("pagination.key")]))
+			}))
+			defer server.Close()
+
+			supply, err := NewGatewayClient(server.Client(), server.URL, 0).GetTotalSupply(context.Background(), 100)
+
+			switch {
+			case tt.wantHeight != nil:
+				var heightErr *rest.HeightError
+				if !errors.As(err, &heightErr) || !errors.Is(err, tt.wantHeight) {
+					t.Errorf("err = %v, want a height error %v", err, tt.wantHeight)
+				}
+			case tt.wantStatus != 0:
+				var statusErr *rest.StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+					t.Errorf("err = %v, want a status error %d", err, tt.wantStatus)
+				}
+			case tt.wantInvalid:
+				if !errors.Is(err, rest.ErrInvalidResponse) {
+					t.Errorf("err = %v, want %v", err, rest.ErrInvalidResponse)
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(supply.Supply) != len(tt.wantDenoms) {
+					t.Fatalf("supply = %s, want denoms %v", supply.Supply, tt.wantDenoms)
+				}
+				for i, coin := range supply.Supply {
+					if coin.Denom != tt.wantDenoms[i] {
+						t.Errorf("supply = %s, want denoms %v", supply.Supply, tt.wantDenoms)
+					}
+				}
+			}
+		})
+	}
+}
@@ -6,14 +6,22 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
 )
 
+// Client reads the distribution module parameters of a Cudos node
+type Client interface {
+	GetParams(ctx context.Context) (ParametersResponse, error)
+}
+
 type client struct {
-	Url string
+	Client *http.Client
+	Url    string
 }
 
-func NewRestClient(url string) *client {
-	return &client{Url: url}
+func NewRestClient(httpClient *http.Client, url string) *client {
+	return &client{Client: httpClient, Url: url}
 }
 
 func (c client) GetParams(ctx context.Context) (ParametersResponse, error) {
@@ -24,10 +32,14 @@ func (c client) GetParams(ctx context.Context) (ParametersResponse, error) {
 
 	var res parametersResult
 	if err := json.Unmarshal([]byte(respStr), &res); err != nil {
-		return ParametersResponse{}, err
+		return ParametersResponse{}, rest.InvalidResponse("%s", err)
 	}
 
-	return res.Result, nil
+	if res.Result == nil || res.Result.CommunityTax == "" {
+		return ParametersResponse{}, rest.InvalidResponse("parameters without community tax")
+	}
+
+	return *res.Result, nil
 }
 
 func (c client) get(ctx context.Context, uri string) (string, error) {
@@ -36,7 +48,7 @@ func (c client) get(ctx context.Context, uri string) (string, error) {
 		return "", err
 	}
 
-	resp, err := http.DefaultClient.Do(getReq)
+	resp, err := c.Client.Do(getReq)
 	if err != nil {
 		return "", err
 	}
@@ -47,11 +59,15 @@ func (c client) get(ctx context.Context, uri string) (string, error) {
 		return "", err
 	}
 
+	if err := rest.CheckResponse(resp.StatusCode, body); err != nil {
+		return "", err
+	}
+
 	return string(body), nil
 }
 
 type parametersResult struct {
-	Result ParametersResponse `json:"result"`
+	Result *ParametersResponse `json:"result"`
 }
 
 type ParametersResponse struct {
@@ -0,0 +1,67 @@
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+)
+
+// gatewayClient queries the gRPC gateway endpoints that replace the legacy endpoints in newer Cosmos SDK versions
+type gatewayClient struct {
+	Client *http.Client
+	Url    string
+}
+
+func NewGatewayClient(httpClient *http.Client, url string) *gatewayClient {
+	return &gatewayClient{Client: httpClient, Url: url}
+}
+
+func (c gatewayClient) GetParams(ctx context.Context) (ParametersResponse, error) {
+	respStr, err := c.get(ctx, "/cosmos/distribution/v1beta1/params")
+	if err != nil {
+		return ParametersResponse{}, err
+	}
+
+	var res gatewayParametersResponse
+	if err := json.Unmarshal([]byte(respStr), &res); err != nil {
+		return ParametersResponse{}, rest.InvalidResponse("%s", err)
+	}
+
+	if res.Params == nil || res.Params.CommunityTax == "" {
+		return ParametersResponse{}, rest.InvalidResponse("parameters without community tax")
+	}
+
+	return *res.Params, nil
+}
+
+func (c gatewayClient) get(ctx context.Context, uri string) (string, error) {
+	getReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s%s", c.Url, uri), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.Client.Do(getReq)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if err := rest.CheckResponse(resp.StatusCode, body); err != nil {
+		return "", err
+	}
+
+	return string(body), nil
+}
+
+type gatewayParametersResponse struct {
+	Params *ParametersResponse `json:"params"`
+}
@@ -0,0 +1,67 @@
+package distribution
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest"
+)
+
+func TestGatewayClientGetParams(t *testing.T) {
+	tests := []struct {
+		name             string
+		statusCode       int
+		body             string
+		wantCommunityTax string
+		wantHeight       error
+		wantStatus       int
+		wantInvalid      bool
+	}{
+		{name: "params", statusCode: http.StatusOK, body: `{"params":{"community_tax":"0.020000000000000000","base_proposer_reward":"0.01","bonus_proposer_reward":"0.04","withdraw_addr_enabled":true}}`, wantCommunityTax: "0.020000000000000000"},
+		{name: "pruned height", statusCode: http.StatusBadRequest, body: `{"code":3,"message":"failed to load state at height 100; version does not exist (latest height: 5000): invalid request","details":[]}`, wantHeight: rest.ErrHeightPruned},
+		{name: "not implemented", statusCode: http.StatusNotImplemented, body: `{"code":12,"message":"Not Implemented","details":[]}`, wantStatus: http.StatusNotImplemented},
+		{name: "without community tax", statusCode: http.StatusOK, body: `{"params":{}}`, wantInvalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/cosmos/distribution/v1beta1/params" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			params, err := NewGatewayClient(server.Client(), server.URL).GetParams(context.Background())
+
+			switch {
+			case tt.wantHeight != nil:
+				var heightErr *rest.HeightError
+				if !errors.As(err, &heightErr) || !errors.Is(err, tt.wantHeight) {
+					t.Errorf("err = %v, want a height error %v", err, tt.wantHeight)
+				}
+			case tt.wantStatus != 0:
+				var statusErr *rest.StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.wantStatus {
+					t.Errorf("err = %v, want a status error %d", err, tt.wantStatus)
+				}
+			case tt.wantInvalid:
+				if !errors.Is(err, rest.ErrInvalidResponse) {
+					t.Errorf("err = %v, want %v", err, rest.ErrInvalidResponse)
+				}
+			default:
+				if err != nil {
+					t.Fatal(err)
+				}
+				if params.CommunityTax != tt.wantCommunityTax {
+					t.Errorf("community tax = %s, want %s", params.CommunityTax, tt.wantCommunityTax)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrHeightPruned is returned when the node no longer keeps the state at the requested height
+	ErrHeightPruned = errors.New("state at height is pruned")
+	// ErrHeightUnknown is returned when the requested height is above the latest height of the node
+	ErrHeightUnknown = errors.New("height is above the latest height")
+	// ErrInvalidResponse is returned when a successful response doesn't have the expected shape
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+// Cosmos SDK nodes answer queries at heights they don't have the state of with this message
+var heightErrorPattern = regexp.MustCompile(`failed to load state at height (\d+);.*\(latest height: (\d+)\)`)
+
+// Error pages are cut to this length so they don't flood the logs
+const maxErrorMessageLength = 200
+
+// HeightError is returned when the node doesn't have the state at the requested height
+type HeightError struct {
+	Height       int64
+	LatestHeight int64
+	Err          error
+}
+
+func (e *HeightError) Error() string {
+	return fmt.Sprintf("%s: height %d, latest height %d", e.Err, e.Height, e.LatestHeight)
+}
+
+func (e *HeightError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError is returned for responses with a non-2xx status that are not height errors
+type StatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Message)
+}
+
+// CheckResponse returns a typed error for responses with a non-2xx status
+func CheckResponse(statusCode int, body []byte) error {
+	if statusCode >= 200 && statusCode < 300 {
+		return nil
+	}
+
+	message := getErrorMessage(body)
+
+	if err := ParseHeightError(message); err != nil {
+		return err
+	}
+
+	return &StatusError{StatusCode: statusCode, Message: message}
+}
+
+// ParseHeightError returns a HeightError when the message reports a missing height and nil otherwise.
+// It applies to the messages of both the REST and the gRPC endpoints.
+func ParseHeightError(message string) error {
+	match := heightErrorPattern.FindStringSubmatch(message)
+	if match == nil {
+		return nil
+	}
+
+	height, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	latestHeight, err := strconv.ParseInt(match[2], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	if height > latestHeight {
+		return &HeightError{Height: height, LatestHeight: latestHeight, Err: ErrHeightUnknown}
+	}
+
+	return &HeightError{Height: height, LatestHeight: latestHeight, Err: ErrHeightPruned}
+}
+
+// InvalidResponse wraps a decoding error or a missing field of a successful response
+func InvalidResponse(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidResponse, fmt.Sprintf(format, args...))
+}
+
+// The legacy endpoints report errors in the error field and the gateway endpoints in the message field
+func getErrorMessage(body []byte) string {
+	var res struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(body, &res); err == nil {
+		if res.Error != "" {
+			return res.Error
+		}
+		if res.Message != "" {
+			return res.Message
+		}
+	}
+
+	message := strings.TrimSpace(string(body))
+	if len(message) > maxErrorMessageLength {
+		message = message[:maxErrorMessageLength] + "..."
+	}
+
+	return message
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// The message Cosmos SDK nodes answer queries at heights they don't have the state of with
+const prunedHeightMessage = "failed to load state at height 100; version does not exist (latest height: 5000)"
+
+func TestParseHeightError(t *testing.T) {
+	tests := []struct {
+		name             string
+		message          string
+		wantErr          error
+		wantHeight       int64
+		wantLatestHeight int64
+	}{
+		{name: "pruned height", message: prunedHeightMessage, wantErr: ErrHeightPruned, wantHeight: 100, wantLatestHeight: 5000},
+		{name: "gateway message", message: prunedHeightMessage + ": invalid request", wantErr: ErrHeightPruned, wantHeight: 100, wantLatestHeight: 5000},
+		{name: "gRPC message", message: "rpc error: code = InvalidArgument desc = " + prunedHeightMessage + ": invalid request", wantErr: ErrHeightPruned, wantHeight: 100, wantLatestHeight: 5000},
+		{name: "height above the latest one", message: "failed to load state at height 6000; version does not exist (latest height: 5000)", wantErr: ErrHeightUnknown, wantHeight: 6000, wantLatestHeight: 5000},
+		{name: "latest height", message: "failed to load state at height 5000; version does not exist (latest height: 5000)", wantErr: ErrHeightPruned, wantHeight: 5000, wantLatestHeight: 5000},
+		{name: "height out of range", message: "failed to load state at height 99999999999999999999; version does not exist (latest height: 5000)"},
+		{name: "other error", message: "rpc error: code = Unavailable desc = connection refused"},
+		{name: "empty message", message: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseHeightError(tt.message)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+
+			var heightErr *HeightError
+			if !errors.As(err, &heightErr) {
+				t.Fatalf("err = %v, want a height error", err)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			if heightErr.Height != tt.wantHeight || heightErr.LatestHeight != tt.wantLatestHeight {
+				t.Errorf("height = %d, latest height = %d, want %d and %d", heightErr.Height, heightErr.LatestHeight, tt.wantHeight, tt.wantLatestHeight)
+			}
+		})
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	longPage := "<html>" + strings.Repeat("x", 300) + "</html>"
+
+	tests := []struct {
+		name        string
+		statusCode  int
+		body        string
+		wantNil     bool
+		wantHeight  error
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "ok", statusCode: http.StatusOK, body: `{"height":"1"}`, wantNil: true},
+		{name: "no content", statusCode: http.StatusNoContent, wantNil: true},
+		{name: "legacy height error", statusCode: http.StatusInternalServerError, body: `{"error":"` + prunedHeightMessage + `"}`, wantHeight: ErrHeightPruned},
+		{name: "gateway height error", statusCode: http.StatusBadRequest, body: `{"code":3,"message":"` + prunedHeightMessage + `: invalid request","details":[]}`, wantHeight: ErrHeightPruned},
+		{name: "legacy error", statusCode: http.StatusBadRequest, body: `{"error":"invalid address"}`, wantStatus: http.StatusBadRequest, wantMessage: "invalid address"},
+		{name: "gateway error", statusCode: http.StatusNotImplemented, body: `{"code":12,"message":"Not Implemented","details":[]}`, wantStatus: http.StatusNotImplemented, wantMessage: "Not Implemented"},
+		{name: "plain text", statusCode: http.StatusBadGateway, body: " bad gateway\n", wantStatus: http.StatusBadGateway, wantMessage: "bad gateway"},
+		{name: "long error page", statusCode: http.StatusServiceUnavailable, body: longPage, wantStatus: http.StatusServiceUnavailable, wantMessage: longPage[:maxErrorMessageLength] + "..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckResponse(tt.statusCode, []byte(tt.body))
+
+			switch {
+			case tt.wantNil:
+				if err != nil {
+					t.Errorf("err = %v, want nil", err)
+				}
+			case tt.wantHeight != nil:
+				if !errors.Is(err, tt.wantHeight) {
+					t.Errorf("err = %v, want %v", err, tt.wantHeight)
+				}
+			default:
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) {
+					t.Fatalf("err = %v, want a status error", err)
+				}
+
+				if statusErr.StatusCode != tt.wantStatus || statusErr.Message != tt.wantMessage {
+					t.Errorf("status error = %d %q, want %d %q", statusErr.StatusCode, statusErr.Message, tt.wantStatus, tt.wantMessage)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+// BlockHeightHeader selects the height gRPC gateway queries are executed at
+const BlockHeightHeader = "x-cosmos-block-height"
+
+// Paths of the latest block, both APIs answer with the block header in the same shape
+const (
+	LegacyLatestBlockPath  = "/blocks/latest"
+	GatewayLatestBlockPath = "/cosmos/base/tendermint/v1beta1/blocks/latest"
+)
+
+// GetLatestHeight returns the height of the latest block at url
+func GetLatestHeight(ctx context.Context, client *http.Client, url string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := CheckResponse(resp.StatusCode, body); err != nil {
+		return 0, err
+	}
+
+	var res latestBlockResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return 0, InvalidResponse("%s", err)
+	}
+
+	height, err := strconv.ParseInt(res.Block.Header.Height, 10, 64)
+	if err != nil {
+		return 0, InvalidResponse("latest block height %s: %s", res.Block.Header.Height, err)
+	}
+
+	return height, nil
+}
+
+type latestBlockResponse struct {
+	Block struct {
+		Header struct {
+			Height string `json:"height"`
+		} `json:"header"`
+	} `json:"block"`
+}
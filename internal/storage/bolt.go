@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	valuesBucket    = []byte("values")
+	updatedAtBucket = []byte("updated_at")
+	historyBucket   = []byte("history")
+
+	// Index of the history by height, every series a nested bucket mapping heights to the keys of their points
+	historyHeightsBucket = []byte("history_heights")
+)
+
+type boltBackend struct {
+	db *bolt.DB
+}
+
+func newBoltBackend(path string) (*boltBackend, error) {
+	if path == "" {
+		return nil, fmt.Errorf("bolt storage requires a path")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %s", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt db %s: %s", path, err)
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{valuesBucket, updatedAtBucket, historyBucket, historyHeightsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return fmt.Errorf("failed to create bucket %s: %s", bucket, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &boltBackend{db: db}, nil
+}
+
+func (b *boltBackend) get(keys ...string) ([]Entry, bool, error) {
+	entries := make([]Entry, len(keys))
+	ok := true
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(valuesBucket)
+		updated := tx.Bucket(updatedAtBucket)
+		for i, key := range keys {
+			// Bolt only guarantees the returned slice while the transaction is open, so it is copied into a string.
+			v := bucket.Get([]byte(key))
+			if v == nil {
+				ok = false
+				return nil
+			}
+			entries[i].Value = string(v)
+
+			t := updated.Get([]byte(key))
+			if t == nil {
+				return fmt.Errorf("update time of %s is missing", key)
+			}
+			entries[i].UpdatedAt = decodeTime(t)
+		}
+		return nil
+	})
+
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	return entries, true, nil
+}
+
+func (b *boltBackend) set(values map[string]string, updatedAt time.Time) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(valuesBucket)
+		updated := tx.Bucket(updatedAtBucket)
+		for key, value := range values {
+			if err := bucket.Put([]byte(key), []byte(value)); err != nil {
+				return fmt.Errorf("failed to put %s: %s", key, err)
+			}
+			if err := updated.Put([]byte(key), encodeTime(updatedAt)); err != nil {
+				return fmt.Errorf("failed to put update time of %s: %s", key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Every series is a nested bucket of the history bucket with the points keyed by their encoded
+// time followed by a sequence number of the bucket, so the natural key order is chronological
+// and points written at the same time don't overwrite each other.
+func (b *boltBackend) appendHistory(points map[string]HistoryPoint, pruneBefore time.Time) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		for series, point := range points {
+			bucket, err := tx.Bucket(historyBucket).CreateBucketIfNotExists([]byte(series))
+			if err != nil {
+				return fmt.Errorf("failed to create history bucket %s: %s", series, err)
+			}
+
+			value, err := json.Marshal(point)
+			if err != nil {
+				return fmt.Errorf("failed to marshal history point %+v: %s", point, err)
+			}
+
+			sequence, err := bucket.NextSequence()
+			if err != nil {
+				return fmt.Errorf("failed to get history sequence of %s: %s", series, err)
+			}
+
+			key := make([]byte, 16)
+			copy(key, encodeTime(point.Time))
+			binary.BigEndian.PutUint64(key[8:], sequence)
+
+			if err := bucket.Put(key, value); err != nil {
+				return fmt.Errorf("failed to put history point for %s: %s", series, err)
+			}
+
+			heights, err := tx.Bucket(historyHeightsBucket).CreateBucketIfNotExists([]byte(series))
+			if err != nil {
+				return fmt.Errorf("failed to create history height index %s: %s", series, err)
+			}
+
+			// The later of points at the same height replaces the earlier one
+			if err := heights.Put(encodeHeight(point.Height), key); err != nil {
+				return fmt.Errorf("failed to index history point for %s: %s", series, err)
+			}
+		}
+
+		if pruneBefore.IsZero() {
+			return nil
+		}
+
+		return tx.Bucket(historyBucket).ForEach(func(series, _ []byte) error {
+			return pruneHistory(tx.Bucket(historyBucket).Bucket(series), tx.Bucket(historyHeightsBucket).Bucket(series), pruneBefore)
+		})
+	})
+}
+
+// Deleting while iterating makes bolt cursors skip keys, so the keys are collected first
+func pruneHistory(bucket, heights *bolt.Bucket, before time.Time) error {
+	if bucket == nil {
+		return nil
+	}
+
+	end := encodeTime(before)
+	var keys [][]byte
+	var pointHeights []int64
+
+	c := bucket.Cursor()
+	for k, v := c.First(); k != nil && bytes.Compare(k[:8], end) < 0; k, v = c.Next() {
+		var point HistoryPoint
+		if err := json.Unmarshal(v, &point); err != nil {
+			return fmt.Errorf("failed to unmarshal history point: %s", err)
+		}
+
+		keys = append(keys, append([]byte{}, k...))
+		pointHeights = append(pointHeights, point.Height)
+	}
+
+	for i, key := range keys {
+		if err := bucket.Delete(key); err != nil {
+			return fmt.Errorf("failed to prune history point: %s", err)
+		}
+
+		// A later point at the same height keeps its index entry
+		if !bytes.Equal(heights.Get(encodeHeight(pointHeights[i])), key) {
+			continue
+		}
+
+		if err := heights.Delete(encodeHeight(pointHeights[i])); err != nil {
+			return fmt.Errorf("failed to prune history height index: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (b *boltBackend) getHistory(series string, from, to time.Time) ([]HistoryPoint, error) {
+	points := []HistoryPoint{}
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket).Bucket([]byte(series))
+		if bucket == nil {
+			return nil
+		}
+
+		end := encodeTime(to)
+		c := bucket.Cursor()
+
+		// Only the time part of the keys is compared so the points at the end time are included whatever their sequence
+		for k, v := c.Seek(encodeTime(from)); k != nil && bytes.Compare(k[:8], end) <= 0; k, v = c.Next() {
+			var point HistoryPoint
+			if err := json.Unmarshal(v, &point); err != nil {
+				return fmt.Errorf("failed to unmarshal history point of %s: %s", series, err)
+			}
+			points = append(points, point)
+		}
+		return nil
+	})
+
+	return points, err
+}
+
+// Seeks the height in the index of the series, the highest indexed height not above it holds the point
+func (b *boltBackend) getHistoryAtHeight(series string, height int64) (HistoryPoint, error) {
+	var point HistoryPoint
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(historyBucket).Bucket([]byte(series))
+		heights := tx.Bucket(historyHeightsBucket).Bucket([]byte(series))
+		if bucket == nil || heights == nil {
+			return ErrKeyNotFound
+		}
+
+		c := heights.Cursor()
+
+		latest, _ := c.Last()
+		if latest == nil {
+			return ErrKeyNotFound
+		}
+		if height > decodeHeight(latest) {
+			return heightNotReached(height, decodeHeight(latest))
+		}
+
+		k, key := c.Seek(encodeHeight(height))
+		if k == nil || decodeHeight(k) > height {
+			k, key = c.Prev()
+		}
+		if k == nil {
+			first, _ := c.First()
+			return heightNotRecorded(height, decodeHeight(first))
+		}
+
+		v := bucket.Get(key)
+		if v == nil {
+			return fmt.Errorf("history point of %s at height %d is missing", series, decodeHeight(k))
+		}
+
+		if err := json.Unmarshal(v, &point); err != nil {
+			return fmt.Errorf("failed to unmarshal history point of %s: %s", series, err)
+		}
+		return nil
+	})
+
+	return point, err
+}
+
+// Heights are encoded as big endian so they sort the same way as the heights themselves
+func encodeHeight(height int64) []byte {
+	key := make([]byte, 8)
+	if height > 0 {
+		binary.BigEndian.PutUint64(key, uint64(height))
+	}
+	return key
+}
+
+func decodeHeight(b []byte) int64 {
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+// Times are encoded as big endian unix nano, which sorts the same way as the times themselves
+func encodeTime(t time.Time) []byte {
+	key := make([]byte, 8)
+
+	// Times before the unix epoch (e.g. the zero time used as an open range) sort first
+	if t.Before(time.Unix(0, 0)) {
+		return key
+	}
+
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+func decodeTime(b []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b))).UTC()
+}
+
+func (b *boltBackend) close() error {
+	return b.db.Close()
+}
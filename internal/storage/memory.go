@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryBackend struct {
+	mu        sync.RWMutex
+	values    map[string]string
+	updatedAt map[string]time.Time
+	history   map[string][]HistoryPoint
+
+	// The points of every series ordered by height, the later of points at the same height last
+	heights map[string][]HistoryPoint
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{
+		values:    make(map[string]string),
+		updatedAt: make(map[string]time.Time),
+		history:   make(map[string][]HistoryPoint),
+		heights:   make(map[string][]HistoryPoint),
+	}
+}
+
+func (b *memoryBackend) get(keys ...string) ([]Entry, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	entries := make([]Entry, len(keys))
+	for i, key := range keys {
+		value, ok := b.values[key]
+		if !ok {
+			return nil, false, nil
+		}
+		entries[i] = Entry{Value: value, UpdatedAt: b.updatedAt[key]}
+	}
+
+	return entries, true, nil
+}
+
+func (b *memoryBackend) set(values map[string]string, updatedAt time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key, value := range values {
+		b.values[key] = value
+		b.updatedAt[key] = updatedAt
+	}
+
+	return nil
+}
+
+func (b *memoryBackend) appendHistory(points map[string]HistoryPoint, pruneBefore time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for series, point := range points {
+		b.history[series] = append(b.history[series], point)
+
+		heights := b.heights[series]
+		i := sort.Search(len(heights), func(i int) bool { return heights[i].Height > point.Height })
+		heights = append(heights, HistoryPoint{})
+		copy(heights[i+1:], heights[i:])
+		heights[i] = point
+		b.heights[series] = heights
+	}
+
+	if !pruneBefore.IsZero() {
+		for series, seriesPoints := range b.history {
+			start := sort.Search(len(seriesPoints), func(i int) bool { return !seriesPoints[i].Time.Before(pruneBefore) })
+			if start == 0 {
+				continue
+			}
+			b.history[series] = seriesPoints[start:]
+
+			var heights []HistoryPoint
+			for _, point := range b.heights[series] {
+				if !point.Time.Before(pruneBefore) {
+					heights = append(heights, point)
+				}
+			}
+			b.heights[series] = heights
+		}
+	}
+
+	return nil
+}
+
+func (b *memoryBackend) getHistory(series string, from, to time.Time) ([]HistoryPoint, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	points := b.history[series]
+	start := sort.Search(len(points), func(i int) bool { return !points[i].Time.Before(from) })
+
+	result := []HistoryPoint{}
+	for _, point := range points[start:] {
+		if point.Time.After(to) {
+			break
+		}
+		result = append(result, point)
+	}
+
+	return result, nil
+}
+
+func (b *memoryBackend) getHistoryAtHeight(series string, height int64) (HistoryPoint, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	heights := b.heights[series]
+	if len(heights) == 0 {
+		return HistoryPoint{}, ErrKeyNotFound
+	}
+
+	if latest := heights[len(heights)-1].Height; height > latest {
+		return HistoryPoint{}, heightNotReached(height, latest)
+	}
+
+	i := sort.Search(len(heights), func(i int) bool { return heights[i].Height > height })
+	if i == 0 {
+		return HistoryPoint{}, heightNotRecorded(height, heights[0].Height)
+	}
+
+	return heights[i-1], nil
+}
+
+func (b *memoryBackend) close() error {
+	return nil
+}
@@ -2,32 +2,161 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendBolt   = "bolt"
 )
 
 type storage struct {
-	values map[string]string
+	backend backend
+
+	// Points older than the retention are pruned when new ones are appended, 0 keeps all of them
+	historyRetention time.Duration
+
+	historyMu       sync.Mutex
+	lastHistoryTime time.Time
+}
+
+// backend is implemented by every place the key-value pairs can live in.
+// Implementations must be safe for concurrent use and apply or read
+// all values passed in a single call atomically.
+type backend interface {
+	get(keys ...string) ([]Entry, bool, error)
+	set(values map[string]string, updatedAt time.Time) error
+	appendHistory(points map[string]HistoryPoint, pruneBefore time.Time) error
+	getHistory(series string, from, to time.Time) ([]HistoryPoint, error)
+	getHistoryAtHeight(series string, height int64) (HistoryPoint, error)
+	close() error
+}
+
+// Entry is a stored value together with the time it was written.
+type Entry struct {
+	Value     string
+	UpdatedAt time.Time
+}
+
+// HistoryPoint is a single value of a series together with the height and time it was calculated at.
+type HistoryPoint struct {
+	Time   time.Time `json:"time"`
+	Height int64     `json:"height"`
+	Value  string    `json:"value"`
 }
 
 func NewStorage() *storage {
 	return &storage{
-		values: make(map[string]string),
+		backend: newMemoryBackend(),
+	}
+}
+
+func NewBoltStorage(path string) (*storage, error) {
+	backend, err := newBoltBackend(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage{backend: backend}, nil
+}
+
+// New creates storage with the given backend type, falling back to in-memory storage when none is set.
+// History points older than historyRetention are pruned, 0 keeps all of them.
+func New(backendType, path string, historyRetention time.Duration) (*storage, error) {
+	var s *storage
+	var err error
+
+	switch backendType {
+	case "", BackendMemory:
+		s = NewStorage()
+	case BackendBolt:
+		s, err = NewBoltStorage(path)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %s", backendType)
+	}
+
+	if err != nil {
+		return nil, err
 	}
+
+	s.historyRetention = historyRetention
+	return s, nil
 }
 
-var ErrKeyNotFound = errors.New("key not found")
+var (
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrHeightNotReached is returned for heights above the latest point of a series
+	ErrHeightNotReached = errors.New("height not reached")
+	// ErrHeightNotRecorded is returned for heights below the earliest point of a series
+	ErrHeightNotRecorded = errors.New("height is before the recorded history")
+)
+
+func heightNotReached(height, latestHeight int64) error {
+	return fmt.Errorf("%w: height %d, latest recorded height %d", ErrHeightNotReached, height, latestHeight)
+}
+
+func heightNotRecorded(height, earliestHeight int64) error {
+	return fmt.Errorf("%w: height %d, earliest recorded height %d", ErrHeightNotRecorded, height, earliestHeight)
+}
 
 func (s *storage) SetValue(key, value string) error {
-	s.values[key] = value
-	return nil
+	return s.SetValues(map[string]string{key: value})
+}
+
+// SetValues writes all values at once, readers either see all of them or none.
+func (s *storage) SetValues(values map[string]string) error {
+	return s.backend.set(values, time.Now().UTC())
 }
 
 func (s *storage) GetValue(key string) (string, error) {
-	value, ok := s.values[key]
+	values, err := s.GetValues(key)
+	if err != nil {
+		return "", err
+	}
+	return values[0], nil
+}
+
+// GetValues reads the values of all keys from the same snapshot and returns them in the order of the keys.
+func (s *storage) GetValues(keys ...string) ([]string, error) {
+	entries, err := s.GetEntries(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(entries))
+	for i, entry := range entries {
+		values[i] = entry.Value
+	}
+	return values, nil
+}
+
+// GetEntries is GetValues that also returns when each value was written.
+func (s *storage) GetEntries(keys ...string) ([]Entry, error) {
+	entries, ok, err := s.backend.get(keys...)
+	if err != nil {
+		return nil, err
+	}
 	if ok == false {
-		return "", ErrKeyNotFound
+		return nil, ErrKeyNotFound
 	}
-	return value, nil
+	return entries, nil
+}
+
+// GetUpdatedAt returns when each of the keys was last written, in the order of the keys.
+func (s *storage) GetUpdatedAt(keys ...string) ([]time.Time, error) {
+	entries, err := s.GetEntries(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt := make([]time.Time, len(entries))
+	for i, entry := range entries {
+		updatedAt[i] = entry.UpdatedAt
+	}
+	return updatedAt, nil
 }
 
 func (s *storage) GetOrDefaultValue(key, defaultValue string) (string, error) {
@@ -49,3 +178,49 @@ func (s *storage) GetInt64Value(key string) (int64, error) {
 	}
 	return strconv.ParseInt(value, 10, 64)
 }
+
+// AppendHistory adds the values calculated at the given height to the series with the same names
+// and prunes the points older than the history retention.
+func (s *storage) AppendHistory(height int64, values map[string]string) error {
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	// UTC() strips the monotonic clock reading, so a wall clock stepping back is kept from reordering the points
+	now := time.Now().UTC()
+	if !now.After(s.lastHistoryTime) {
+		now = s.lastHistoryTime.Add(time.Nanosecond)
+	}
+
+	points := make(map[string]HistoryPoint, len(values))
+	for series, value := range values {
+		points[series] = HistoryPoint{Time: now, Height: height, Value: value}
+	}
+
+	var pruneBefore time.Time
+	if s.historyRetention > 0 {
+		pruneBefore = now.Add(-s.historyRetention)
+	}
+
+	if err := s.backend.appendHistory(points, pruneBefore); err != nil {
+		return err
+	}
+
+	s.lastHistoryTime = now
+	return nil
+}
+
+// GetHistory returns the points of the series recorded between from and to (inclusive), oldest first.
+func (s *storage) GetHistory(series string, from, to time.Time) ([]HistoryPoint, error) {
+	return s.backend.getHistory(series, from, to)
+}
+
+// GetHistoryAtHeight returns the point of the series that was in effect at the given height,
+// which is the one calculated at the highest height not above it. ErrKeyNotFound is returned
+// while the series is empty, ErrHeightNotReached and ErrHeightNotRecorded for heights outside of it.
+func (s *storage) GetHistoryAtHeight(series string, height int64) (HistoryPoint, error) {
+	return s.backend.getHistoryAtHeight(series, height)
+}
+
+func (s *storage) Close() error {
+	return s.backend.close()
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStorages(t *testing.T, historyRetention time.Duration) map[string]*storage {
+	bolt, err := New(BackendBolt, filepath.Join(t.TempDir(), "stats.db"), historyRetention)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { bolt.Close() })
+
+	memory, err := New(BackendMemory, "", historyRetention)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]*storage{BackendBolt: bolt, BackendMemory: memory}
+}
+
+func TestGetHistoryAtHeight(t *testing.T) {
+	tests := []struct {
+		name      string
+		height    int64
+		wantValue string
+		wantErr   error
+	}{
+		{name: "before the history", height: 99, wantErr: ErrHeightNotRecorded},
+		{name: "first point", height: 100, wantValue: "a"},
+		{name: "between points", height: 150, wantValue: "a"},
+		{name: "later point at the same height", height: 200, wantValue: "c"},
+		{name: "point recorded out of order", height: 250, wantValue: "e"},
+		{name: "latest point", height: 300, wantValue: "d"},
+		{name: "after the history", height: 301, wantErr: ErrHeightNotReached},
+	}
+
+	for backend, s := range newTestStorages(t, 0) {
+		for i, point := range []struct {
+			height int64
+			value  string
+		}{{100, "a"}, {200, "b"}, {200, "c"}, {300, "d"}, {250, "e"}} {
+			if err := s.AppendHistory(point.height, map[string]string{"inflation": point.value}); err != nil {
+				t.Fatalf("%s: point %d: %s", backend, i, err)
+			}
+		}
+
+		for _, tt := range tests {
+			t.Run(backend+"/"+tt.name, func(t *testing.T) {
+				point, err := s.GetHistoryAtHeight("inflation", tt.height)
+				if tt.wantErr != nil {
+					if !errors.Is(err, tt.wantErr) {
+						t.Fatalf("err = %v, want %v", err, tt.wantErr)
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if point.Value != tt.wantValue {
+					t.Errorf("value = %s, want %s", point.Value, tt.wantValue)
+				}
+			})
+		}
+
+		t.Run(backend+"/empty series", func(t *testing.T) {
+			if _, err := s.GetHistoryAtHeight("apr", 100); !errors.Is(err, ErrKeyNotFound) {
+				t.Errorf("err = %v, want %v", err, ErrKeyNotFound)
+			}
+		})
+	}
+}
+
+func TestGetHistoryAtHeightAfterPruning(t *testing.T) {
+	for backend, s := range newTestStorages(t, time.Hour) {
+		t.Run(backend, func(t *testing.T) {
+			if err := s.AppendHistory(100, map[string]string{"inflation": "a"}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Moving the last point back makes the next one prune the first
+			s.lastHistoryTime = time.Now().UTC().Add(2 * time.Hour)
+
+			if err := s.AppendHistory(200, map[string]string{"inflation": "b"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.GetHistoryAtHeight("inflation", 150); !errors.Is(err, ErrHeightNotRecorded) {
+				t.Errorf("err = %v, want %v", err, ErrHeightNotRecorded)
+			}
+
+			point, err := s.GetHistoryAtHeight("inflation", 200)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if point.Value != "b" {
+				t.Errorf("value = %s, want b", point.Value)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package tasks
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	cudoMintTypes "github.com/CudoVentures/cudos-node/x/cudoMint/types"
@@ -13,7 +15,7 @@ import (
 	"github.com/forbole/juno/v2/node/remote"
 )
 
-func getCalculateAPRHandler(genesisState cudoMintTypes.GenesisState, cfg config.Config, nodeClient *remote.Node, stakingClient stakingtypes.QueryClient,
+func getCalculateAPRHandler(genesisState cudoMintTypes.GenesisState, cfg config.Config, nodeClient nodeClient, stakingClient stakingtypes.QueryClient,
 	distClient distributionQueryClient, storage keyValueStorage) func() error {
 
 	return func() error {
@@ -35,11 +37,28 @@ func getCalculateAPRHandler(genesisState cudoMintTypes.GenesisState, cfg config.
 			return fmt.Errorf("failed to parse RealBlocksPerDay %s", cfg.APRGenesis.RealBlocksPerDay)
 		}
 
-		mintAmountInt, err := calculateMintedTokensSinceHeight(genesisState, cfg.APRGenesis.InitialHeight, latestBlockHeight, 30.43, realBlocksPerDay)
+		checkpoint, err := getNormTimeCheckpoint(storage, cfg.Storage.APRNormTimeCheckpointKey, genesisState, cfg.APRGenesis.InitialHeight, latestBlockHeight)
+		if err != nil {
+			return err
+		}
+
+		mintParams := genesisState
+		mintParams.Minter.NormTimePassed = checkpoint.NormTimePassed
+
+		mintAmountInt, err := calculateMintedTokensSinceHeight(mintParams, checkpoint.Height, latestBlockHeight, 30.43, realBlocksPerDay)
 		if err != nil {
 			return fmt.Errorf("failed to calculated minted tokens: %s", err)
 		}
 
+		checkpointJSON, err := json.Marshal(normTimeCheckpoint{
+			Genesis:        checkpoint.Genesis,
+			Height:         latestBlockHeight,
+			NormTimePassed: updateNormTimePassed(mintParams, checkpoint.Height, latestBlockHeight),
+		})
+		if err != nil {
+			return fmt.Errorf("error while converting norm time checkpoint to JSON: %s", err)
+		}
+
 		ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*10)
 		defer cancelFunc()
 
@@ -66,18 +85,23 @@ func getCalculateAPRHandler(genesisState cudoMintTypes.GenesisState, cfg config.
 			apr = apr.Mul(communityTaxPortion)
 		}
 
-		if err := storage.SetValue(cfg.Storage.APRKey, apr.String()); err != nil {
-			return fmt.Errorf("failed to set value %s for key %s", apr.String(), cfg.Storage.APRKey)
-		}
+		annualProvisions := mintAmountInt.ToDec().MulInt64(12)
 
-		if err := storage.SetInt64Value(cfg.Storage.APRHeightKey, latestBlockHeight); err != nil {
-			return fmt.Errorf("failed to set value %d for key %s", latestBlockHeight, cfg.Storage.APRHeightKey)
+		if err := storage.SetValues(map[string]string{
+			cfg.Storage.APRKey:              apr.String(),
+			cfg.Storage.APRHeightKey:        strconv.FormatInt(latestBlockHeight, 10),
+			cfg.Storage.AnnualProvisionsKey: annualProvisions.String(),
+			// Saved together with the values so the checkpoint never runs ahead of them
+			cfg.Storage.APRNormTimeCheckpointKey: string(checkpointJSON),
+		}); err != nil {
+			return fmt.Errorf("failed to set apr values at height %d: %s", latestBlockHeight, err)
 		}
 
-		annualProvisions := mintAmountInt.ToDec().MulInt64(12)
-
-		if err := storage.SetValue(cfg.Storage.AnnualProvisionsKey, annualProvisions.String()); err != nil {
-			return fmt.Errorf("failed to set value %s for key %s", annualProvisions.String(), cfg.Storage.AnnualProvisionsKey)
+		if err := storage.AppendHistory(latestBlockHeight, map[string]string{
+			cfg.Storage.APRKey:              apr.String(),
+			cfg.Storage.AnnualProvisionsKey: annualProvisions.String(),
+		}); err != nil {
+			return fmt.Errorf("failed to append apr history at height %d: %s", latestBlockHeight, err)
 		}
 
 		return nil
@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// ErrHeightBeforeGenesis is returned for heights below the initial height of the inflation genesis
+var ErrHeightBeforeGenesis = errors.New("height is before the genesis height")
+
+// ErrCalculationPending is returned when a calculation takes longer than a request may wait for it. The
+// calculation goes on and its result is cached for the next request.
+var ErrCalculationPending = errors.New("calculation is still running")
+
+// Values at a past height never change, so this many of the latest requested heights are kept per value
+const heightCacheSize = 1024
+
+// Requests wait this long for a calculation, well within the write timeout of the HTTP server
+const calculationWait = 8 * time.Second
+
+// HeightCalculator calculates values at a requested height on demand, for heights the history does not cover
+type HeightCalculator struct {
+	cfg           config.Config
+	bankingClient bankQueryClient
+	blocksPerDay  int64
+
+	inflations    *lru.Cache
+	totalSupplies *lru.Cache
+
+	mu      sync.Mutex
+	pending map[calculationKey]*pendingCalculation
+	wait    time.Duration
+}
+
+type inflationAtHeight struct {
+	inflation    string
+	method       string
+	calculatedAt time.Time
+}
+
+type totalSupplyAtHeight struct {
+	supply       bank.TotalSupplyResponse
+	calculatedAt time.Time
+}
+
+type calculationKey struct {
+	value  string
+	height int64
+}
+
+// pendingCalculation is shared by the requests of the same value at the same height
+type pendingCalculation struct {
+	done   chan struct{}
+	result interface{}
+	err    error
+}
+
+func NewHeightCalculator(cfg config.Config, bankingClient bankQueryClient) (*HeightCalculator, error) {
+	genesisState, err := createGenesisState(cfg.InflationGenesis.NormTimePassed, cfg.InflationGenesis.BlocksPerDay)
+	if err != nil {
+		return nil, err
+	}
+
+	inflations, err := lru.New(heightCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalSupplies, err := lru.New(heightCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HeightCalculator{
+		cfg:           cfg,
+		bankingClient: bankingClient,
+		blocksPerDay:  genesisState.Params.BlocksPerDay.Int64(),
+		inflations:    inflations,
+		totalSupplies: totalSupplies,
+		pending:       map[calculationKey]*pendingCalculation{},
+		wait:          calculationWait,
+	}, nil
+}
+
+// GetInflationAtHeight returns the inflation at the height together with the method it was calculated with and
+// the time it was calculated at. Ethereum blocks can't be matched to a past Cudos height, so only the Cudos supply
+// is taken into account.
+func (c *HeightCalculator) GetInflationAtHeight(height int64) (string, string, time.Time, error) {
+	if err := c.checkHeight(height); err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	result, err := c.calculate(c.inflations, calculationKey{value: "inflation", height: height}, func() (interface{}, error) {
+		startHeight, sinceDays, err := getInflationStartHeight(height, c.cfg.Calculation.InflationSinceDays, c.blocksPerDay)
+		if err != nil {
+			return nil, err
+		}
+
+		startSupply, err := getCudosNetworkCirculatingSupplyAtHeight(startHeight, c.bankingClient, c.cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		currentSupply, err := getCudosNetworkCirculatingSupplyAtHeight(height, c.bankingClient, c.cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		inflation, err := calculateInflation(startSupply, currentSupply, sinceDays)
+		if err != nil {
+			return nil, err
+		}
+
+		return inflationAtHeight{
+			inflation:    inflation.String(),
+			method:       getInflationMethod(inflationSourceCudos, sinceDays),
+			calculatedAt: time.Now().UTC(),
+		}, nil
+	})
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	inflation := result.(inflationAtHeight)
+	return inflation.inflation, inflation.method, inflation.calculatedAt, nil
+}
+
+// GetTotalSupplyAtHeight returns the supply of all tokens at the height the same way the supply task stores it
+// together with the time it was calculated at
+func (c *HeightCalculator) GetTotalSupplyAtHeight(height int64) (bank.TotalSupplyResponse, time.Time, error) {
+	if err := c.checkHeight(height); err != nil {
+		return bank.TotalSupplyResponse{}, time.Time{}, err
+	}
+
+	result, err := c.calculate(c.totalSupplies, calculationKey{value: "total_supply", height: height}, func() (interface{}, error) {
+		supply, err := getAllTokensSupplyAtHeight(height, c.bankingClient, c.cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		return totalSupplyAtHeight{supply: supply.totalSupply, calculatedAt: time.Now().UTC()}, nil
+	})
+	if err != nil {
+		return bank.TotalSupplyResponse{}, time.Time{}, err
+	}
+
+	supply := result.(totalSupplyAtHeight)
+	return supply.supply, supply.calculatedAt, nil
+}
+
+// calculate returns the cached result or waits for the calculation, which runs once however many requests wait
+// for it. Requests stop waiting after c.wait while the calculation goes on to fill the cache.
+func (c *HeightCalculator) calculate(cache *lru.Cache, key calculationKey, calculate func() (interface{}, error)) (interface{}, error) {
+	if cached, ok := cache.Get(key.height); ok {
+		return cached, nil
+	}
+
+	c.mu.Lock()
+
+	// The result is cached before the calculation stops pending, so it is looked up again under the lock
+	if cached, ok := cache.Get(key.height); ok {
+		c.mu.Unlock()
+		return cached, nil
+	}
+
+	calculation, ok := c.pending[key]
+	if !ok {
+		calculation = &pendingCalculation{done: make(chan struct{})}
+		c.pending[key] = calculation
+
+		go func() {
+			calculation.result, calculation.err = calculate()
+			if calculation.err == nil {
+				cache.Add(key.height, calculation.result)
+			}
+
+			c.mu.Lock()
+			delete(c.pending, key)
+			c.mu.Unlock()
+
+			close(calculation.done)
+		}()
+	}
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.wait)
+	defer timer.Stop()
+
+	select {
+	case <-calculation.done:
+		return calculation.result, calculation.err
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: %s at height %d", ErrCalculationPending, key.value, key.height)
+	}
+}
+
+func (c *HeightCalculator) checkHeight(height int64) error {
+	if height < c.cfg.InflationGenesis.InitialHeight {
+		return fmt.Errorf("%w: height %d, genesis height %d", ErrHeightBeforeGenesis, height, c.cfg.InflationGenesis.InitialHeight)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// countingBankClient grows the supply with the height and counts the supply queries
+type countingBankClient struct {
+	supplyCalls int
+}
+
+func (c *countingBankClient) GetTotalSupply(ctx context.Context, height int64) (bank.TotalSupplyResponse, error) {
+	c.supplyCalls++
+	return bank.TotalSupplyResponse{Supply: sdk.NewCoins(sdk.NewInt64Coin("acudos", 1e18+height))}, nil
+}
+
+func (c *countingBankClient) GetBalance(ctx context.Context, height int64, address, denom string) (sdk.Coin, error) {
+	return sdk.NewInt64Coin(denom, 1), nil
+}
+
+func newTestHeightCalculator(t *testing.T) (*HeightCalculator, *countingBankClient) {
+	var cfg config.Config
+	cfg.InflationGenesis.InitialHeight = 1000
+	cfg.InflationGenesis.NormTimePassed = "0.53172694105988"
+	cfg.InflationGenesis.BlocksPerDay = "100"
+	cfg.InflationGenesis.MintDenom = "acudos"
+	cfg.InflationGenesis.GravityAccountAddress = "cudos16n3lc7cywa68mg50qhp847034w88pntq8823tx"
+	cfg.Calculation.InflationSinceDays = 30
+
+	client := &countingBankClient{}
+
+	calculator, err := NewHeightCalculator(cfg, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return calculator, client
+}
+
+func TestHeightCalculatorRejectsHeightsBeforeGenesis(t *testing.T) {
+	calculator, client := newTestHeightCalculator(t)
+
+	if _, _, _, err := calculator.GetInflationAtHeight(999); !errors.Is(err, ErrHeightBeforeGenesis) {
+		t.Errorf("inflation err = %v, want %v", err, ErrHeightBeforeGenesis)
+	}
+
+	if _, _, err := calculator.GetTotalSupplyAtHeight(999); !errors.Is(err, ErrHeightBeforeGenesis) {
+		t.Errorf("total supply err = %v, want %v", err, ErrHeightBeforeGenesis)
+	}
+
+	if client.supplyCalls != 0 {
+		t.Errorf("node was queried %d times", client.supplyCalls)
+	}
+}
+
+func TestHeightCalculatorCachesHeights(t *testing.T) {
+	calculator, client := newTestHeightCalculator(t)
+
+	inflation, method, calculatedAt, err := calculator.GetInflationAtHeight(5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := client.supplyCalls
+
+	cachedInflation, cachedMethod, cachedAt, err := calculator.GetInflationAtHeight(5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cachedInflation != inflation || cachedMethod != method {
+		t.Errorf("cached inflation = %s %s, want %s %s", cachedInflation, cachedMethod, inflation, method)
+	}
+
+	// The calculation time is served as the update time, so it has to stay the same for the cached value
+	if !cachedAt.Equal(calculatedAt) {
+		t.Errorf("cached calculation time = %s, want %s", cachedAt, calculatedAt)
+	}
+
+	if _, _, err := calculator.GetTotalSupplyAtHeight(5000); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := calculator.GetTotalSupplyAtHeight(5000); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cached inflation is not read again and the total supply of all tokens only once
+	if client.supplyCalls != calls+2 {
+		t.Errorf("node was queried %d times after the first inflation, want 2", client.supplyCalls-calls)
+	}
+
+	if _, _, _, err := calculator.GetInflationAtHeight(5001); err != nil {
+		t.Fatal(err)
+	}
+	if client.supplyCalls == calls+2 {
+		t.Error("another height was served from the cache")
+	}
+}
+
+// blockingBankClient answers the supply queries once release is closed
+type blockingBankClient struct {
+	countingBankClient
+	release chan struct{}
+}
+
+func (c *blockingBankClient) GetTotalSupply(ctx context.Context, height int64) (bank.TotalSupplyResponse, error) {
+	<-c.release
+	return c.countingBankClient.GetTotalSupply(ctx, height)
+}
+
+func TestHeightCalculatorStopsWaitingForSlowCalculations(t *testing.T) {
+	var cfg config.Config
+	cfg.InflationGenesis.InitialHeight = 1000
+	cfg.InflationGenesis.NormTimePassed = "0.53172694105988"
+	cfg.InflationGenesis.BlocksPerDay = "100"
+	cfg.InflationGenesis.MintDenom = "acudos"
+
+	client := &blockingBankClient{release: make(chan struct{})}
+
+	calculator, err := NewHeightCalculator(cfg, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calculator.wait = 20 * time.Millisecond
+
+	if _, _, err := calculator.GetTotalSupplyAtHeight(5000); !errors.Is(err, ErrCalculationPending) {
+		t.Fatalf("err = %v, want %v", err, ErrCalculationPending)
+	}
+
+	// Waiting again joins the running calculation instead of starting another one
+	calculator.wait = time.Second
+	close(client.release)
+
+	supply, _, err := calculator.GetTotalSupplyAtHeight(5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if supply.Supply.AmountOf("acudos").IsZero() {
+		t.Errorf("supply = %s", supply.Supply)
+	}
+
+	// One calculation reads the total supply twice, for the circulating supply and for the other tokens
+	if client.supplyCalls != 2 {
+		t.Errorf("node was queried %d times, want 2", client.supplyCalls)
+	}
+}
@@ -0,0 +1,69 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cudoMintTypes "github.com/CudoVentures/cudos-node/x/cudoMint/types"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/rs/zerolog/log"
+)
+
+// normTimeCheckpoint is the norm time passed reached at a height, so the next run
+// continues from there instead of progressing it all the way from the genesis again.
+type normTimeCheckpoint struct {
+	Genesis        string  `json:"genesis"`
+	Height         int64   `json:"height"`
+	NormTimePassed sdk.Dec `json:"norm_time_passed"`
+}
+
+// Returns the stored checkpoint if it was made from the same genesis values and is not
+// past the given height, otherwise the genesis itself is used as a starting point.
+func getNormTimeCheckpoint(storage keyValueStorage, key string, genesisState cudoMintTypes.GenesisState, genesisInitialHeight, height int64) (normTimeCheckpoint, error) {
+	genesis := normTimeCheckpoint{
+		Genesis:        getGenesisFingerprint(genesisState, genesisInitialHeight),
+		Height:         genesisInitialHeight,
+		NormTimePassed: genesisState.Minter.NormTimePassed,
+	}
+
+	value, err := storage.GetOrDefaultValue(key, "")
+	if err != nil {
+		return normTimeCheckpoint{}, fmt.Errorf("failed to get %s: %s", key, err)
+	}
+
+	if value == "" {
+		return genesis, nil
+	}
+
+	var checkpoint normTimeCheckpoint
+	if err := json.Unmarshal([]byte(value), &checkpoint); err != nil {
+		log.Warn().Err(err).Msgf("invalid %s, recomputing from genesis", key)
+		return genesis, nil
+	}
+
+	if checkpoint.Genesis != genesis.Genesis {
+		log.Info().Msgf("genesis values changed since %s was saved, recomputing from genesis", key)
+		return genesis, nil
+	}
+
+	if checkpoint.Height > height || checkpoint.Height < genesisInitialHeight {
+		return genesis, nil
+	}
+
+	return checkpoint, nil
+}
+
+// ResetNormTimeCheckpoint drops the stored checkpoint, so the next APR run progresses the norm time
+// from the genesis again even though the genesis values didn't change.
+func ResetNormTimeCheckpoint(cfg config.Config, storage keyValueStorage) error {
+	if err := storage.SetValue(cfg.Storage.APRNormTimeCheckpointKey, ""); err != nil {
+		return fmt.Errorf("failed to reset %s: %s", cfg.Storage.APRNormTimeCheckpointKey, err)
+	}
+
+	return nil
+}
+
+func getGenesisFingerprint(genesisState cudoMintTypes.GenesisState, genesisInitialHeight int64) string {
+	return fmt.Sprintf("%d/%s/%s", genesisInitialHeight, genesisState.Minter.NormTimePassed, genesisState.Params.BlocksPerDay)
+}
@@ -0,0 +1,119 @@
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/storage"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestGetNormTimeCheckpoint(t *testing.T) {
+	const key = "apr_norm_time_checkpoint"
+	const genesisHeight = 1000
+
+	genesisState, err := createGenesisState("0.53172694105988", "17280")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changedGenesisState, err := createGenesisState("0.6", "17280")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := func(genesis string, height int64) string {
+		value, err := json.Marshal(normTimeCheckpoint{Genesis: genesis, Height: height, NormTimePassed: sdk.MustNewDecFromStr("0.7")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(value)
+	}
+
+	fingerprint := getGenesisFingerprint(*genesisState, genesisHeight)
+
+	tests := []struct {
+		name           string
+		value          string
+		height         int64
+		wantCheckpoint bool
+	}{
+		{name: "nothing saved", height: 5000},
+		{name: "saved", value: saved(fingerprint, 4000), height: 5000, wantCheckpoint: true},
+		{name: "saved at the height", value: saved(fingerprint, 5000), height: 5000, wantCheckpoint: true},
+		{name: "changed genesis", value: saved(getGenesisFingerprint(*changedGenesisState, genesisHeight), 4000), height: 5000},
+		{name: "changed genesis height", value: saved(getGenesisFingerprint(*genesisState, 2000), 4000), height: 5000},
+		{name: "future height", value: saved(fingerprint, 6000), height: 5000},
+		{name: "before genesis", value: saved(fingerprint, 500), height: 5000},
+		{name: "corrupt", value: "{\"genesis\":", height: 5000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewStorage()
+			if tt.value != "" {
+				if err := store.SetValue(key, tt.value); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			checkpoint, err := getNormTimeCheckpoint(store, key, *genesisState, genesisHeight, tt.height)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if checkpoint.Genesis != fingerprint {
+				t.Errorf("genesis = %s, want %s", checkpoint.Genesis, fingerprint)
+			}
+
+			if tt.wantCheckpoint {
+				if !checkpoint.NormTimePassed.Equal(sdk.MustNewDecFromStr("0.7")) {
+					t.Errorf("checkpoint = %+v, want the saved one", checkpoint)
+				}
+				return
+			}
+
+			if checkpoint.Height != genesisHeight || !checkpoint.NormTimePassed.Equal(genesisState.Minter.NormTimePassed) {
+				t.Errorf("checkpoint = %+v, want the genesis", checkpoint)
+			}
+		})
+	}
+}
+
+func TestResetNormTimeCheckpoint(t *testing.T) {
+	var cfg config.Config
+	cfg.Storage.APRNormTimeCheckpointKey = "apr_norm_time_checkpoint"
+
+	genesisState, err := createGenesisState("0.53172694105988", "17280")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := json.Marshal(normTimeCheckpoint{
+		Genesis:        getGenesisFingerprint(*genesisState, 1000),
+		Height:         4000,
+		NormTimePassed: sdk.MustNewDecFromStr("0.7"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := storage.NewStorage()
+	if err := store.SetValue(cfg.Storage.APRNormTimeCheckpointKey, string(value)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ResetNormTimeCheckpoint(cfg, store); err != nil {
+		t.Fatal(err)
+	}
+
+	checkpoint, err := getNormTimeCheckpoint(store, cfg.Storage.APRNormTimeCheckpointKey, *genesisState, 1000, 5000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if checkpoint.Height != 1000 {
+		t.Errorf("checkpoint height = %d, want the genesis height 1000", checkpoint.Height)
+	}
+}
@@ -0,0 +1,109 @@
+package tasks
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/erc20"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/erc20/erc20test"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ethAccountA = common.HexToAddress("0xe4422BCDc20E93014F67b73d4120b878c4246804")
+	ethAccountB = common.HexToAddress("0x03638Df94502181386a9A8b0382652D6Ab3E5B08")
+	ethAccountC = common.HexToAddress("0x5F321d2ED6772B64d33Fce6942504cdCAB1Ca1da")
+)
+
+func deployTestToken(t *testing.T) *erc20test.Token {
+	supply, _ := new(big.Int).SetString(maxSupply, 10)
+	token := erc20test.Deploy(t, supply)
+
+	token.Transfer(t, ethAccountA, big.NewInt(1000))
+	token.Transfer(t, ethAccountB, big.NewInt(250))
+	token.Transfer(t, ethAccountC, big.NewInt(7))
+
+	return token
+}
+
+// The simulated backend only serves calls at its latest block
+func getLatestTestBlock(t *testing.T, token *erc20test.Token) *big.Int {
+	block, err := getLatestEthBlock(token.Backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return block
+}
+
+func TestGetEthAccountsBalanceAtBlock(t *testing.T) {
+	token := deployTestToken(t)
+
+	caller, err := erc20.NewTokenCaller(token.Address, token.Backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := getLatestTestBlock(t, token)
+
+	tests := []struct {
+		name     string
+		accounts []string
+		want     int64
+	}{
+		{name: "no accounts", accounts: nil, want: 0},
+		{name: "one account", accounts: []string{ethAccountA.Hex()}, want: 1000},
+		{name: "several accounts", accounts: []string{ethAccountA.Hex(), ethAccountB.Hex(), ethAccountC.Hex()}, want: 1257},
+		{name: "account without tokens", accounts: []string{"0x924A59d9EBE85E37Ef9Fd56714F00094395EABa3"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			balance, err := getEthAccountsBalanceAtBlock(caller, tt.accounts, block)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if balance.Cmp(big.NewInt(tt.want)) != 0 {
+				t.Errorf("balance = %s, want %d", balance, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEthAccountsBalanceAtBlockFailsOnCallError(t *testing.T) {
+	token := deployTestToken(t)
+
+	caller, err := erc20.NewTokenCaller(token.Address, token.Backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A block the backend can't serve makes the call fail instead of counting a zero balance
+	block := new(big.Int).Add(getLatestTestBlock(t, token), big.NewInt(100))
+
+	if _, err := getEthAccountsBalanceAtBlock(caller, []string{ethAccountA.Hex()}, block); err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestGetEthCirculatingSupplyAtHeight(t *testing.T) {
+	token := deployTestToken(t)
+
+	var cfg config.Config
+	cfg.Eth.TokenAddress = token.Address.Hex()
+	cfg.Eth.EthAccounts = []string{ethAccountA.Hex(), ethAccountB.Hex()}
+
+	supply, err := getEthCirculatingSupplyAtHeight(getLatestTestBlock(t, token), token.Backend, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := sdk.NewIntFromString(maxSupply)
+	want = want.SubRaw(1250)
+
+	if !supply.Equal(want) {
+		t.Errorf("supply = %s, want %s", supply, want)
+	}
+}
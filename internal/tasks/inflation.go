@@ -2,128 +2,149 @@ package tasks
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"strconv"
 	"time"
 
 	cudoMintTypes "github.com/CudoVentures/cudos-node/x/cudoMint/types"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/erc20"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
 	sdk "github.com/cosmos/cosmos-sdk/types"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/forbole/juno/v2/node/remote"
+	"github.com/ethereum/go-ethereum/common"
 )
 
-func getCalculateInflationHandler(genesisState cudoMintTypes.GenesisState, cfg config.Config, nodeClient *remote.Node, bankingClient bankQueryClient, storage keyValueStorage) func() error {
-	return func() error {
-		//client, err := ethclient.Dial(cfg.Eth.EthNode)
-		//if err != nil {
-		//	return fmt.Errorf("failed to dial eth node: %s", err)
-		//}
-
-		//latestEthBlock, err := getLatestEthBlock(client)
-		//if err != nil {
-		//	return fmt.Errorf("faield to get latest eth block: %s", err)
-		//}
-
-		//inflationEthStartBlock := big.NewInt(latestEthBlock.Int64() - (cfg.Calculation.InflationSinceDays * ethBlocksPerDay))
-
-		//ethStartSupply, err := getEthCirculatingSupplyAtHeight(inflationEthStartBlock, client, cfg)
-		//if err != nil {
-		//	return err
-		//}
-
-		// ethCurrentSupply, err := getEthCirculatingSupplyAtHeight(latestEthBlock, client, cfg)
-		// if err != nil {
-		// 	return err
-		// }
+func getCalculateInflationHandler(genesisState cudoMintTypes.GenesisState, cfg config.Config, nodeClient nodeClient, bankingClient bankQueryClient,
+	ethClient ethBackend, storage keyValueStorage) func() error {
 
+	return func() error {
 		latestCudosBlock, err := nodeClient.LatestHeight()
 		if err != nil {
 			return fmt.Errorf("failed to get last block height %s", err)
 		}
 
-		inflationCudosStartBlock := int64(0)
-		inflationSinceDaysValue := int64(cfg.Calculation.InflationSinceDays)
-
-		// TODO: This can be removed after the chain is working for more than INFLATION_SINCE_DAYS
-
-		for inflationCudosStartBlock < 1 {
-			inflationCudosStartBlock = latestCudosBlock - (inflationSinceDaysValue * genesisState.Params.BlocksPerDay.Int64())
-			inflationSinceDaysValue--
+		inflationCudosStartBlock, inflationSinceDays, err := getInflationStartHeight(latestCudosBlock, cfg.Calculation.InflationSinceDays, genesisState.Params.BlocksPerDay.Int64())
+		if err != nil {
+			return err
 		}
 
-		//cudosStartSupply, err := getCudosNetworkCirculatingSupplyAtHeight(inflationCudosStartBlock, bankingClient, cfg)
-		//if err != nil {
-		//	return err
-		//}
+		cudosStartSupply, err := getCudosNetworkCirculatingSupplyAtHeight(inflationCudosStartBlock, bankingClient, cfg)
+		if err != nil {
+			return err
+		}
 
 		cudosCurrentSupply, err := getCudosNetworkCirculatingSupplyAtHeight(latestCudosBlock, bankingClient, cfg)
 		if err != nil {
 			return err
 		}
 
-		//startTotalSupply := ethStartSupply.Add(cudosStartSupply)
-		// currentTotalSupply := ethCurrentSupply.Add(cudosCurrentSupply)
-		currentTotalSupply := cudosCurrentSupply.Sub(sdk.NewIntWithDecimal(1942421346, 18))
+		startSupply := cudosStartSupply
+		currentSupply := cudosCurrentSupply
+		inflationSource := inflationSourceCudos
+		ethValues := map[string]string{}
 
-		//inflation := currentTotalSupply.Sub(startTotalSupply).ToDec().Quo(startTotalSupply.ToDec())
-		inflation := sdk.MustNewDecFromStr("0.01")
+		if cfg.Eth.Enabled {
+			latestEthBlock, err := getLatestEthBlock(ethClient)
+			if err != nil {
+				return err
+			}
 
-		if err := storage.SetValue(cfg.Storage.InflationKey, inflation.String()); err != nil {
-			return fmt.Errorf("failed to set value %s for key %s", inflation.String(), cfg.Storage.InflationKey)
-		}
+			inflationEthStartBlock := big.NewInt(latestEthBlock.Int64() - (inflationSinceDays * ethBlocksPerDay))
 
-		if err := storage.SetInt64Value(cfg.Storage.InflationHeightKey, latestCudosBlock); err != nil {
-			return fmt.Errorf("failed to set value %d for key %s", latestCudosBlock, cfg.Storage.InflationHeightKey)
-		}
+			ethStartSupply, err := getEthCirculatingSupplyAtHeight(inflationEthStartBlock, ethClient, cfg)
+			if err != nil {
+				return err
+			}
 
-		ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*10)
-		defer cancelFunc()
+			ethCurrentSupply, err := getEthCirculatingSupplyAtHeight(latestEthBlock, ethClient, cfg)
+			if err != nil {
+				return err
+			}
+
+			startSupply = startSupply.Add(ethStartSupply)
+			currentSupply = currentSupply.Add(ethCurrentSupply)
+			inflationSource = inflationSourceCudosEth
 
-		totalSupply, err := bankingClient.GetTotalSupply(ctx, latestCudosBlock)
+			ethValues[cfg.Storage.EthSupplyKey] = ethCurrentSupply.String()
+			ethValues[cfg.Storage.EthSupplyHeightKey] = latestEthBlock.String()
+		}
+
+		inflation, err := calculateInflation(startSupply, currentSupply, inflationSinceDays)
 		if err != nil {
-			return fmt.Errorf("error while getting total supply: %s", err)
+			return err
 		}
 
-		var cudosNetworkTotalSupply sdk.Int
+		inflationMethod := getInflationMethod(inflationSource, inflationSinceDays)
 
-		for i := 0; i < len(totalSupply.Supply); i++ {
-			if totalSupply.Supply[i].Denom == cfg.InflationGenesis.MintDenom {
-				cudosNetworkTotalSupply = totalSupply.Supply[i].Amount
-				totalSupply.Supply[i].Amount = currentTotalSupply
-			}
+		values := map[string]string{
+			cfg.Storage.InflationKey:       inflation.String(),
+			cfg.Storage.InflationHeightKey: strconv.FormatInt(latestCudosBlock, 10),
+			cfg.Storage.InflationMethodKey: inflationMethod,
 		}
 
-		totalSupplyJSON, err := json.Marshal(totalSupply)
-		if err != nil {
-			return fmt.Errorf("error while convering supply to JSON: %s", err)
+		for key, value := range ethValues {
+			values[key] = value
 		}
 
-		if err := storage.SetValue(cfg.Storage.AllTokensSupplyKey, string(totalSupplyJSON)); err != nil {
-			return fmt.Errorf("failed to set value %s for key %s", string(totalSupplyJSON), cfg.Storage.AllTokensSupplyKey)
+		if err := storage.SetValues(values); err != nil {
+			return fmt.Errorf("failed to set inflation values at height %d: %s", latestCudosBlock, err)
 		}
 
-		if err := storage.SetValue(cfg.Storage.SupplyKey, currentTotalSupply.String()); err != nil {
-			return fmt.Errorf("failed to set value %s for key %s", currentTotalSupply.String(), cfg.Storage.SupplyKey)
+		history := map[string]string{
+			cfg.Storage.InflationKey: inflation.String(),
 		}
 
-		if err := storage.SetInt64Value(cfg.Storage.SupplyHeightKey, latestCudosBlock); err != nil {
-			return fmt.Errorf("failed to set value %d for key %s", latestCudosBlock, cfg.Storage.SupplyHeightKey)
+		if ethSupply, ok := ethValues[cfg.Storage.EthSupplyKey]; ok {
+			history[cfg.Storage.EthSupplyKey] = ethSupply
 		}
 
-		if err := storage.SetValue(cfg.Storage.CudosNetworkTotalSupplyKey, cudosNetworkTotalSupply.String()); err != nil {
-			return fmt.Errorf("failed to set value %s for key %s", cudosNetworkTotalSupply.String(), cfg.Storage.CudosNetworkTotalSupplyKey)
+		// The eth supply point is recorded at the cudos height as well so all series share one height axis
+		if err := storage.AppendHistory(latestCudosBlock, history); err != nil {
+			return fmt.Errorf("failed to append inflation history at height %d: %s", latestCudosBlock, err)
 		}
 
 		return nil
 	}
 }
 
-func getEthCirculatingSupplyAtHeight(height *big.Int, client *ethclient.Client, cfg config.Config) (sdk.Int, error) {
+// ErrChainTooYoung is returned when the chain at a height is not a day old yet
+var ErrChainTooYoung = errors.New("chain is too young to calculate inflation")
+
+// Shortens the period while the chain is younger than sinceDays
+func getInflationStartHeight(latestHeight, sinceDays, blocksPerDay int64) (int64, int64, error) {
+	for sinceDays > 0 && latestHeight-sinceDays*blocksPerDay < 1 {
+		sinceDays--
+	}
+
+	if sinceDays == 0 {
+		return 0, 0, fmt.Errorf("%w at height %d", ErrChainTooYoung, latestHeight)
+	}
 
-	ethAccountsBalance, err := getEthAccountsBalanceAtBlock(client, cfg.Eth.TokenAddress, cfg.Eth.EthAccounts, height)
+	return latestHeight - sinceDays*blocksPerDay, sinceDays, nil
+}
+
+func getInflationMethod(source string, sinceDays int64) string {
+	return fmt.Sprintf("%s_change_over_%d_days", source, sinceDays)
+}
+
+// Annualized relative change of the supply over the period
+func calculateInflation(startSupply, currentSupply sdk.Int, periodDays int64) (sdk.Dec, error) {
+	if !startSupply.IsPositive() {
+		return sdk.Dec{}, fmt.Errorf("invalid start supply %s", startSupply)
+	}
+
+	return currentSupply.Sub(startSupply).ToDec().Quo(startSupply.ToDec()).MulInt64(daysPerYear).QuoInt64(periodDays), nil
+}
+
+func getEthCirculatingSupplyAtHeight(height *big.Int, client ethBackend, cfg config.Config) (sdk.Int, error) {
+	token, err := erc20.NewTokenCaller(common.HexToAddress(cfg.Eth.TokenAddress), client)
+	if err != nil {
+		return sdk.Int{}, fmt.Errorf("failed to bind token %s: %s", cfg.Eth.TokenAddress, err)
+	}
+
+	ethAccountsBalance, err := getEthAccountsBalanceAtBlock(token, cfg.Eth.EthAccounts, height)
 	if err != nil {
 		return sdk.Int{}, fmt.Errorf("failed to get eth accounts balance: %s", err)
 	}
@@ -146,17 +167,23 @@ func getCudosNetworkCirculatingSupplyAtHeight(height int64, bankingClient bankQu
 	var err error
 
 	// TODO: Just hack to iterate until you reach block with non-empty supply, should be removed when we are not accessing so old blocks
+	// Errors end the search, as neither a pruned nor an unknown height becomes available at the next height.
+	startHeight := height
 
 	for {
 		totalSupply, err = bankingClient.GetTotalSupply(ctx, height)
 		if err != nil {
-			return sdk.Int{}, fmt.Errorf("error while getting total supply: %s", err)
+			return sdk.Int{}, fmt.Errorf("error while getting total supply: %w", err)
 		}
 
 		if len(totalSupply.Supply) != 0 {
 			break
 		}
 
+		if height-startHeight >= maxSkippedHeights {
+			return sdk.Int{}, fmt.Errorf("no total supply between heights %d and %d", startHeight, height)
+		}
+
 		height += 1
 	}
 
@@ -165,13 +192,18 @@ func getCudosNetworkCirculatingSupplyAtHeight(height int64, bankingClient bankQu
 	for {
 		gravityModuleBalance, err = bankingClient.GetBalance(ctx, height, cfg.InflationGenesis.GravityAccountAddress, cfg.InflationGenesis.MintDenom)
 		if err != nil {
-			return sdk.Int{}, fmt.Errorf("error while getting %s balance: %s", cfg.InflationGenesis.GravityAccountAddress, err)
+			return sdk.Int{}, fmt.Errorf("error while getting %s balance: %w", cfg.InflationGenesis.GravityAccountAddress, err)
 		}
 
-		if !gravityModuleBalance.Amount.IsZero() {
+		// Accounts without any balance are returned as an empty coin
+		if !gravityModuleBalance.Amount.IsNil() && !gravityModuleBalance.Amount.IsZero() {
 			break
 		}
 
+		if height-startHeight >= maxSkippedHeights {
+			return sdk.Int{}, fmt.Errorf("no %s balance between heights %d and %d", cfg.InflationGenesis.GravityAccountAddress, startHeight, height)
+		}
+
 		height += 1
 	}
 
@@ -0,0 +1,240 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/metrics"
+	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/go-co-op/gocron"
+	"github.com/rs/zerolog/log"
+)
+
+// Runner executes the tasks and keeps track of how their runs went
+type Runner struct {
+	tasks []task
+
+	mu       sync.RWMutex
+	statuses map[string]*TaskStatus
+	jobs     map[string]*gocron.Job
+}
+
+// TaskStatus describes the last run of a task and when it is going to run next
+type TaskStatus struct {
+	Name         string     `json:"name"`
+	Running      bool       `json:"running"`
+	LastStart    *time.Time `json:"last_start,omitempty"`
+	LastSuccess  *time.Time `json:"last_success,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time `json:"last_error_at,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+}
+
+func NewRunner(cfg config.Config, nodeClient nodeClient, stakingClient stakingtypes.QueryClient, bankingClient bankQueryClient,
+	distClient distributionQueryClient, ethClient ethBackend, storage keyValueStorage) (*Runner, error) {
+
+	tasks, err := getTasks(cfg, nodeClient, stakingClient, bankingClient, distClient, ethClient, storage)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]*TaskStatus, len(tasks))
+	for _, t := range tasks {
+		statuses[t.name] = &TaskStatus{Name: t.name}
+	}
+
+	return &Runner{
+		tasks:    tasks,
+		statuses: statuses,
+		jobs:     make(map[string]*gocron.Job),
+	}, nil
+}
+
+func (r *Runner) ExecuteTasks() error {
+	// Tasks are independent of each other, so one failing does not hold back the others
+	errs := make(chan error, len(r.tasks))
+
+	for _, t := range r.tasks {
+		go func(t task) {
+			errs <- r.run(t, time.Time{})
+		}(t)
+	}
+
+	var failed []string
+
+	for range r.tasks {
+		if err := <-errs; err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d tasks failed: %s", len(failed), len(r.tasks), strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+func (r *Runner) RegisterTasks(scheduler *gocron.Scheduler) error {
+	for _, t := range r.tasks {
+		if err := r.scheduleTask(scheduler, t); err != nil {
+			return fmt.Errorf("scheduler failed to register task %s: %s", t.name, err)
+		}
+	}
+
+	return nil
+}
+
+// GetStatuses returns a snapshot of the statuses of all tasks in the order they are executed
+func (r *Runner) GetStatuses() []TaskStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	statuses := make([]TaskStatus, 0, len(r.tasks))
+
+	for _, t := range r.tasks {
+		status := *r.statuses[t.name]
+
+		if job, ok := r.jobs[t.name]; ok {
+			if nextRun := job.NextRun(); !nextRun.IsZero() {
+				status.NextRun = &nextRun
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// Tasks are scheduled either by a cron expression or at a fixed interval. They are
+// executed once at startup already, so interval tasks wait for their first slot.
+func (r *Runner) scheduleTask(scheduler *gocron.Scheduler, t task) error {
+	switch {
+	case t.schedule.Cron != "" && t.schedule.Interval != 0:
+		return errors.New("only one of cron and interval can be set")
+	case t.schedule.Cron != "":
+		scheduler.Cron(t.schedule.Cron)
+	case t.schedule.Interval > 0:
+		scheduler.Every(t.schedule.Interval).WaitForSchedule()
+	default:
+		return errors.New("either cron or a positive interval has to be set")
+	}
+
+	// Singleton mode skips a run while the previous scheduled one of the same task is still going,
+	// run skips it while the startup run is
+	job, err := scheduler.SingletonMode().DoWithJobDetails(func(job gocron.Job) {
+		watchMethod(func() error {
+			return r.run(t, job.NextRun())
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.jobs[t.name] = job
+	r.mu.Unlock()
+
+	return nil
+}
+
+// run skips the run when another one of the same task, e.g. the startup run, is still going
+func (r *Runner) run(t task, deadline time.Time) error {
+	start := time.Now().UTC()
+
+	if !r.startRun(t.name, start) {
+		log.Warn().Msgf("%s is still running, skipping this run", t.name)
+		return nil
+	}
+
+	err := runWithRetry(t, deadline)
+
+	metrics.ObserveTask(t.name, start, err)
+
+	end := time.Now().UTC()
+
+	r.updateStatus(t.name, func(status *TaskStatus) {
+		status.Running = false
+		status.LastDuration = end.Sub(start).String()
+
+		if err != nil {
+			status.LastError = err.Error()
+			status.LastErrorAt = &end
+		} else {
+			status.LastSuccess = &end
+		}
+	})
+
+	return err
+}
+
+// startRun marks the task as running unless it already is
+func (r *Runner) startRun(name string, start time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.statuses[name]
+	if status.Running {
+		return false
+	}
+
+	status.Running = true
+	status.LastStart = &start
+
+	return true
+}
+
+func (r *Runner) updateStatus(name string, update func(status *TaskStatus)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	update(r.statuses[name])
+}
+
+// runWithRetry runs the task until it succeeds, it runs out of attempts or the next attempt
+// would start after the deadline, which for scheduled runs is the next slot of the task.
+func runWithRetry(t task, deadline time.Time) error {
+	attempts := t.schedule.Retry.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	backoff := t.schedule.Retry.InitialBackoff
+	attempt := 1
+
+	for {
+		err := t.handler()
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= attempts {
+			return fmt.Errorf("%s failed after %d attempts: %s", t.name, attempt, err)
+		}
+
+		if !deadline.IsZero() && time.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("%s failed after %d attempts, giving up before the next run: %s", t.name, attempt, err)
+		}
+
+		log.Warn().Err(err).Msgf("%s failed, retrying in %s (attempt %d of %d)", t.name, backoff, attempt, attempts)
+
+		time.Sleep(backoff)
+
+		attempt++
+		backoff = getNextBackoff(backoff, t.schedule.Retry.MaxBackoff)
+	}
+}
+
+func getNextBackoff(backoff, maxBackoff time.Duration) time.Duration {
+	backoff *= 2
+	if maxBackoff > 0 && backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunSkipsWhileTaskIsRunning(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	slow := task{name: "slow", handler: func() error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return nil
+	}}
+
+	r := &Runner{tasks: []task{slow}, statuses: map[string]*TaskStatus{slow.name: {Name: slow.name}}}
+
+	startup := make(chan error)
+	go func() {
+		startup <- r.ExecuteTasks()
+	}()
+
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// A scheduled run while the startup run is going is skipped
+	if err := r.run(slow, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	close(release)
+	if err := <-startup; err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+
+	// Once the startup run is done the scheduled runs go ahead
+	if err := r.run(slow, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := atomic.LoadInt32(&calls); calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
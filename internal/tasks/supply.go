@@ -0,0 +1,160 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func getCalculateSupplyHandler(cfg config.Config, nodeClient nodeClient, bankingClient bankQueryClient, storage keyValueStorage) func() error {
+	return func() error {
+		latestCudosBlock, err := nodeClient.LatestHeight()
+		if err != nil {
+			return fmt.Errorf("failed to get last block height %s", err)
+		}
+
+		supply, err := getAllTokensSupplyAtHeight(latestCudosBlock, bankingClient, cfg)
+		if err != nil {
+			return err
+		}
+
+		currentTotalSupply := supply.excludedAccounts.CirculatingSupply
+		cudosNetworkTotalSupply := supply.cudosNetworkTotalSupply
+
+		excludedAccountsJSON, err := json.Marshal(supply.excludedAccounts)
+		if err != nil {
+			return fmt.Errorf("error while converting excluded accounts to JSON: %s", err)
+		}
+
+		totalSupplyJSON, err := json.Marshal(supply.totalSupply)
+		if err != nil {
+			return fmt.Errorf("error while convering supply to JSON: %s", err)
+		}
+
+		if err := storage.SetValues(map[string]string{
+			cfg.Storage.AllTokensSupplyKey:         string(totalSupplyJSON),
+			cfg.Storage.SupplyKey:                  currentTotalSupply.String(),
+			cfg.Storage.SupplyHeightKey:            strconv.FormatInt(latestCudosBlock, 10),
+			cfg.Storage.CudosNetworkTotalSupplyKey: cudosNetworkTotalSupply.String(),
+			cfg.Storage.ExcludedAccountsKey:        string(excludedAccountsJSON),
+		}); err != nil {
+			return fmt.Errorf("failed to set supply values at height %d: %s", latestCudosBlock, err)
+		}
+
+		if err := storage.AppendHistory(latestCudosBlock, map[string]string{
+			cfg.Storage.SupplyKey:                  currentTotalSupply.String(),
+			cfg.Storage.CudosNetworkTotalSupplyKey: cudosNetworkTotalSupply.String(),
+		}); err != nil {
+			return fmt.Errorf("failed to append supply history at height %d: %s", latestCudosBlock, err)
+		}
+
+		return nil
+	}
+}
+
+// The supply of all tokens is reported with the circulating supply in place of the total supply of the mint denom
+func getAllTokensSupplyAtHeight(height int64, bankingClient bankQueryClient, cfg config.Config) (allTokensSupplyAtHeight, error) {
+	cudosCurrentSupply, err := getCudosNetworkCirculatingSupplyAtHeight(height, bankingClient, cfg)
+	if err != nil {
+		return allTokensSupplyAtHeight{}, err
+	}
+
+	excludedAccounts, err := getExcludedAccountsAtHeight(height, cudosCurrentSupply, bankingClient, cfg)
+	if err != nil {
+		return allTokensSupplyAtHeight{}, err
+	}
+
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancelFunc()
+
+	totalSupply, err := bankingClient.GetTotalSupply(ctx, height)
+	if err != nil {
+		return allTokensSupplyAtHeight{}, fmt.Errorf("error while getting total supply: %w", err)
+	}
+
+	var cudosNetworkTotalSupply sdk.Int
+
+	for i := 0; i < len(totalSupply.Supply); i++ {
+		if totalSupply.Supply[i].Denom == cfg.InflationGenesis.MintDenom {
+			cudosNetworkTotalSupply = totalSupply.Supply[i].Amount
+			totalSupply.Supply[i].Amount = excludedAccounts.CirculatingSupply
+		}
+	}
+
+	return allTokensSupplyAtHeight{
+		totalSupply:             totalSupply,
+		cudosNetworkTotalSupply: cudosNetworkTotalSupply,
+		excludedAccounts:        excludedAccounts,
+	}, nil
+}
+
+// Circulating supply is the total supply without the live balances of the excluded accounts and the fixed excluded amount
+func getExcludedAccountsAtHeight(height int64, totalSupply sdk.Int, bankingClient bankQueryClient, cfg config.Config) (excludedAccountsAtHeight, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancelFunc()
+
+	result := excludedAccountsAtHeight{
+		Height:              height,
+		TotalSupply:         totalSupply,
+		ExcludedTotal:       sdk.ZeroInt(),
+		FixedExcludedAmount: sdk.ZeroInt(),
+		Accounts:            []excludedAccountBalance{},
+	}
+
+	if cfg.Calculation.FixedExcludedAmount != "" {
+		fixedAmount, ok := sdk.NewIntFromString(cfg.Calculation.FixedExcludedAmount)
+		if !ok {
+			return excludedAccountsAtHeight{}, fmt.Errorf("invalid fixed excluded amount %s", cfg.Calculation.FixedExcludedAmount)
+		}
+		result.FixedExcludedAmount = fixedAmount
+		result.ExcludedTotal = fixedAmount
+	}
+
+	for _, account := range cfg.Calculation.ExcludedAccounts {
+		balance, err := bankingClient.GetBalance(ctx, height, account.Address, cfg.InflationGenesis.MintDenom)
+		if err != nil {
+			return excludedAccountsAtHeight{}, fmt.Errorf("error while getting excluded account %s balance: %w", account.Address, err)
+		}
+
+		// Every client returns a zero amount for accounts without a balance of the denom
+		amount := balance.Amount
+
+		result.Accounts = append(result.Accounts, excludedAccountBalance{
+			Address:  account.Address,
+			Category: account.Category,
+			Balance:  amount,
+		})
+		result.ExcludedTotal = result.ExcludedTotal.Add(amount)
+	}
+
+	result.CirculatingSupply = totalSupply.Sub(result.ExcludedTotal)
+
+	return result, nil
+}
+
+type allTokensSupplyAtHeight struct {
+	totalSupply             bank.TotalSupplyResponse
+	cudosNetworkTotalSupply sdk.Int
+	excludedAccounts        excludedAccountsAtHeight
+}
+
+type excludedAccountsAtHeight struct {
+	Height              int64                    `json:"height"`
+	TotalSupply         sdk.Int                  `json:"total_supply"`
+	ExcludedTotal       sdk.Int                  `json:"excluded_total"`
+	FixedExcludedAmount sdk.Int                  `json:"fixed_excluded_amount"`
+	CirculatingSupply   sdk.Int                  `json:"circulating_supply"`
+	Accounts            []excludedAccountBalance `json:"accounts"`
+}
+
+type excludedAccountBalance struct {
+	Address  string  `json:"address"`
+	Category string  `json:"category"`
+	Balance  sdk.Int `json:"balance"`
+}
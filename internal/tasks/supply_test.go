@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestGetExcludedAccountsAtHeight(t *testing.T) {
+	tests := []struct {
+		name            string
+		accounts        []config.ExcludedAccount
+		fixedAmount     string
+		wantExcluded    int64
+		wantCirculating int64
+	}{
+		{
+			name:            "accounts",
+			accounts:        []config.ExcludedAccount{{Address: "cudos1treasury", Category: "treasury"}, {Address: "cudos1gravity", Category: "gravity"}},
+			wantExcluded:    2,
+			wantCirculating: 998,
+		},
+		{
+			name:            "gravity account and fixed amount",
+			accounts:        []config.ExcludedAccount{{Address: "cudos1gravity", Category: "gravity"}},
+			fixedAmount:     "500",
+			wantExcluded:    501,
+			wantCirculating: 499,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg config.Config
+			cfg.InflationGenesis.MintDenom = "acudos"
+			cfg.Calculation.ExcludedAccounts = tt.accounts
+			cfg.Calculation.FixedExcludedAmount = tt.fixedAmount
+
+			// Every account of the counting client holds 1 acudos
+			result, err := getExcludedAccountsAtHeight(100, sdk.NewInt(1000), &countingBankClient{}, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !result.ExcludedTotal.Equal(sdk.NewInt(tt.wantExcluded)) {
+				t.Errorf("excluded total = %s, want %d", result.ExcludedTotal, tt.wantExcluded)
+			}
+
+			if !result.CirculatingSupply.Equal(sdk.NewInt(tt.wantCirculating)) {
+				t.Errorf("circulating supply = %s, want %d", result.CirculatingSupply, tt.wantCirculating)
+			}
+
+			if len(result.Accounts) != len(tt.accounts) {
+				t.Errorf("accounts = %v, want %d", result.Accounts, len(tt.accounts))
+			}
+		})
+	}
+}
@@ -4,71 +4,60 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"strconv"
 	"time"
 
 	cudoMintTypes "github.com/CudoVentures/cudos-node/x/cudoMint/types"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
-	"github.com/CudoVentures/cudos-stats-v2-service/internal/erc20"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/bank"
 	"github.com/CudoVentures/cudos-stats-v2-service/internal/rest/distribution"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	stakingtypes "github.com/cosmos/cosmos-sdk/x/staking/types"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/forbole/juno/v2/node/remote"
-	"github.com/go-co-op/gocron"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/rs/zerolog/log"
 )
 
-func ExecuteTasks(cfg config.Config, nodeClient *remote.Node, stakingClient stakingtypes.QueryClient, bankingClient bankQueryClient,
-	distClient distributionQueryClient, storage keyValueStorage) error {
-
-	inflationGenesisState, err := createGenesisState(cfg.InflationGenesis.NormTimePassed, cfg.InflationGenesis.BlocksPerDay)
-	if err != nil {
-		return err
-	}
-
-	if err := getCalculateInflationHandler(*inflationGenesisState, cfg, nodeClient, bankingClient, storage)(); err != nil {
-		return fmt.Errorf("inflation calculation failed: %s", err)
-	}
-
-	aprGenesisState, err := createGenesisState(cfg.APRGenesis.NormTimePassed, cfg.APRGenesis.BlocksPerDay)
-	if err != nil {
-		return err
-	}
-
-	if err := getCalculateAPRHandler(*aprGenesisState, cfg, nodeClient, stakingClient, distClient, storage)(); err != nil {
-		return fmt.Errorf("apr calculation failed: %s", err)
-	}
-
-	return nil
+type task struct {
+	name     string
+	schedule config.TaskConfig
+	handler  func() error
 }
 
-func RegisterTasks(scheduler *gocron.Scheduler, cfg config.Config, nodeClient *remote.Node, stakingClient stakingtypes.QueryClient, bankingClient bankQueryClient,
-	distClient distributionQueryClient, storage keyValueStorage) error {
+func getTasks(cfg config.Config, nodeClient nodeClient, stakingClient stakingtypes.QueryClient, bankingClient bankQueryClient,
+	distClient distributionQueryClient, ethClient ethBackend, storage keyValueStorage) ([]task, error) {
 
 	inflationGenesisState, err := createGenesisState(cfg.InflationGenesis.NormTimePassed, cfg.InflationGenesis.BlocksPerDay)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	aprGenesisState, err := createGenesisState(cfg.APRGenesis.NormTimePassed, cfg.APRGenesis.BlocksPerDay)
 	if err != nil {
-		return err
-	}
-
-	if _, err := scheduler.Every(1).Day().At("00:00").Do(func() {
-		watchMethod(getCalculateInflationHandler(*inflationGenesisState, cfg, nodeClient, bankingClient, storage))
-		watchMethod(getCalculateAPRHandler(*aprGenesisState, cfg, nodeClient, stakingClient, distClient, storage))
-	}); err != nil {
-		return fmt.Errorf("scheduler failed to register tasks: %s", err)
+		return nil, err
 	}
 
-	return nil
+	return []task{
+		{
+			name:     "supply",
+			schedule: cfg.Tasks.Supply,
+			handler:  getCalculateSupplyHandler(cfg, nodeClient, bankingClient, storage),
+		},
+		{
+			name:     "inflation",
+			schedule: cfg.Tasks.Inflation,
+			handler:  getCalculateInflationHandler(*inflationGenesisState, cfg, nodeClient, bankingClient, ethClient, storage),
+		},
+		{
+			name:     "apr",
+			schedule: cfg.Tasks.APR,
+			handler:  getCalculateAPRHandler(*aprGenesisState, cfg, nodeClient, stakingClient, distClient, storage),
+		},
+	}, nil
 }
 
-func getLatestEthBlock(client *ethclient.Client) (*big.Int, error) {
+func getLatestEthBlock(client ethBackend) (*big.Int, error) {
 	header, err := client.HeaderByNumber(context.Background(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get latest eth block: %s", err)
@@ -77,23 +66,20 @@ func getLatestEthBlock(client *ethclient.Client) (*big.Int, error) {
 	return header.Number, nil
 }
 
-func getEthAccountsBalanceAtBlock(client *ethclient.Client, tokenAddress string, accounts []string, block *big.Int) (*big.Int, error) {
-	instance, err := erc20.NewTokenCaller(common.HexToAddress(tokenAddress), client)
-	if err != nil {
-		return nil, err
-	}
-
+func getEthAccountsBalanceAtBlock(token tokenBalanceCaller, accounts []string, block *big.Int) (*big.Int, error) {
 	totalBalance := big.NewInt(0)
 
 	for _, account := range accounts {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
 
-		balance, err := instance.BalanceOf(&bind.CallOpts{
+		balance, err := token.BalanceOf(&bind.CallOpts{
 			BlockNumber: block,
 			Context:     ctx,
 		}, common.HexToAddress(account))
 
+		// Canceled right away instead of deferred, which would keep the context of every account until the loop ends
+		cancel()
+
 		if err != nil {
 			return nil, err
 		}
@@ -114,55 +100,26 @@ func calculateMintedTokensSinceHeight(mintParams cudoMintTypes.GenesisState, gen
 
 	minter.NormTimePassed = updateNormTimePassed(mintParams, genesisInitialHeight, sinceBlock)
 
-	mintAmountInt := sdk.NewInt(0)
-
 	// We have to predict what the block count will be periodDays from now. Because
 	// mintParams.Params.BlocksPerDay is intentionally wrong, using that would give
 	// us the wrong result. We use the "real blocks per day" instead.
 	totalBlocks := int64(float64(realBlocksPerDay.Int64()) * periodDays)
 
-	for height := int64(1); height <= totalBlocks; height++ {
-		if minter.NormTimePassed.GT(finalNormTimePassed) {
-			break
-		}
-
-		incr := normalizeBlockHeightInc(params.BlocksPerDay)
-		mintAmountDec := calculateMintedCoins(minter, incr)
-		mintAmountInt = mintAmountInt.Add(mintAmountDec.TruncateInt())
-		minter.NormTimePassed = minter.NormTimePassed.Add(incr)
-	}
+	endNormTimePassed := minter.NormTimePassed.Add(normalizeBlockHeightInc(params.BlocksPerDay).MulInt64(totalBlocks))
 
-	return mintAmountInt, nil
+	return calculateMintedCoins(minter.NormTimePassed, endNormTimePassed).TruncateInt(), nil
 }
 
+// Every block advances the norm time by the same increment, so the norm time at
+// lastBlockHeight is reached in one step instead of adding it block by block.
 func updateNormTimePassed(mintParams cudoMintTypes.GenesisState, initialBlockHeight, lastBlockHeight int64) sdk.Dec {
-	// TODO: Cannot be saved at this moment because of the changes in inflation calculation
-	// storage := workers.NewWorkersStorage(db, "cudomint")
-	// valueStr, err := storage.GetOrDefaultValue(calculateInflationLastBlock, strconv.FormatInt(initialBlockHeight, 10))
-	// if err != nil {
-	// 	return sdk.Dec{}, fmt.Errorf("failed to get %s", calculateInflationLastBlock)
-	// }
-
-	// value, err := strconv.ParseInt(valueStr, 10, 64)
-	// if err != nil {
-	// 	return sdk.Dec{}, fmt.Errorf("failed to parse %s", calculateInflationLastBlock)
-	// }
-
-	for initialBlockHeight < lastBlockHeight {
-		inc := normalizeBlockHeightInc(mintParams.Params.BlocksPerDay)
-		mintParams.Minter.NormTimePassed = mintParams.Minter.NormTimePassed.Add(inc)
-		initialBlockHeight++
+	if lastBlockHeight <= initialBlockHeight {
+		return mintParams.Minter.NormTimePassed
 	}
 
-	// if err := db.SaveMintParams(&mintParams); err != nil {
-	// 	return sdk.Dec{}, fmt.Errorf("failed to save mint params: %s", err)
-	// }
-
-	// if err := storage.SetValue(calculateInflationLastBlock, strconv.FormatInt(lastBlockHeight, 10)); err != nil {
-	// 	return sdk.Dec{}, fmt.Errorf("failed to save %s: %s", calculateInflationLastBlock, err)
-	// }
+	inc := normalizeBlockHeightInc(mintParams.Params.BlocksPerDay)
 
-	return mintParams.Minter.NormTimePassed
+	return mintParams.Minter.NormTimePassed.Add(inc.MulInt64(lastBlockHeight - initialBlockHeight))
 }
 
 // Normalize block height incrementation
@@ -177,9 +134,13 @@ func calculateIntegral(t sdk.Dec) sdk.Dec {
 	return (zeroPointSix.Mul(t.Power(3))).Sub(twentySixPointFive.Mul(t.Power(2))).Add(sdk.NewDec(358).Mul(t))
 }
 
-func calculateMintedCoins(minter cudoMintTypes.Minter, increment sdk.Dec) sdk.Dec {
-	prevStep := calculateIntegral(sdk.MinDec(minter.NormTimePassed, finalNormTimePassed))
-	nextStep := calculateIntegral(sdk.MinDec(minter.NormTimePassed.Add(increment), finalNormTimePassed))
+// Minted amount between two norm time points is the difference of the integral at them. Summing it
+// block by block telescopes to the same difference, as every block's end point is the next one's start
+// and adding and subtracting decimals is exact, so only truncating every block's amount to whole acudos
+// made the per block sum smaller, by less than one acudos per block.
+func calculateMintedCoins(fromNormTimePassed, toNormTimePassed sdk.Dec) sdk.Dec {
+	prevStep := calculateIntegral(sdk.MinDec(fromNormTimePassed, finalNormTimePassed))
+	nextStep := calculateIntegral(sdk.MinDec(toNormTimePassed, finalNormTimePassed))
 	return (nextStep.Sub(prevStep)).Mul(sdk.NewDec(10).Power(24)) // formula calculates in mil of cudos + converting to acudos
 }
 
@@ -197,13 +158,21 @@ func createGenesisState(normTimePassedStr, blocksPerDayStr string) (*cudoMintTyp
 	return cudoMintTypes.NewGenesisState(cudoMintTypes.NewMinter(sdk.NewDec(0), normTimePassed), cudoMintTypes.NewParams(blocksPerDay)), nil
 }
 
+// GetBlocksPerYear returns the blocks minted in a year of 365.25 days at the blocks per day of the config
+func GetBlocksPerYear(blocksPerDayStr string) (uint64, error) {
+	blocksPerDay, err := strconv.ParseUint(blocksPerDayStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse BlocksPerDay %s: %s", blocksPerDayStr, err)
+	}
+
+	return blocksPerDay * 36525 / 100, nil
+}
+
 func watchMethod(method func() error) {
-	go func() {
-		err := method()
-		if err != nil {
-			log.Error().Err(err).Send()
-		}
-	}()
+	err := method()
+	if err != nil {
+		log.Error().Err(err).Send()
+	}
 }
 
 var (
@@ -213,23 +182,45 @@ var (
 	finalNormTimePassed = sdk.NewDec(10)
 	zeroPointSix        = sdk.MustNewDecFromStr("0.6")
 	twentySixPointFive  = sdk.MustNewDecFromStr("26.5")
-	// calculateInflationLastBlock = "CalculateInflationLastBlock"
 )
 
 const ethBlocksPerDay = 5760
+const daysPerYear = 365
+const inflationSourceCudos = "cudos_supply"
+const inflationSourceCudosEth = "cudos_eth_supply"
 const maxSupply = "10000000000000000000000000000" // 10 billion
 
+// Limits how many heights after a requested one are tried while the supply or the gravity balance is empty there
+const maxSkippedHeights = 100
+
 type keyValueStorage interface {
 	SetValue(key, value string) error
+	SetValues(values map[string]string) error
+	AppendHistory(height int64, values map[string]string) error
 	SetInt64Value(key string, value int64) error
 	GetOrDefaultValue(key, defaultValue string) (string, error)
 }
 
+type nodeClient interface {
+	LatestHeight() (int64, error)
+}
+
 type bankQueryClient interface {
 	GetTotalSupply(ctx context.Context, height int64) (bank.TotalSupplyResponse, error)
 	GetBalance(ctx context.Context, height int64, address, denom string) (sdk.Coin, error)
 }
 
+// ethBackend is satisfied by *ethclient.Client as well as by go-ethereum's simulated backend
+type ethBackend interface {
+	bind.ContractCaller
+	HeaderByNumber(ctx context.Context, number *big.Int) (*ethtypes.Header, error)
+}
+
+// tokenBalanceCaller is satisfied by *erc20.TokenCaller
+type tokenBalanceCaller interface {
+	BalanceOf(opts *bind.CallOpts, tokenOwner common.Address) (*big.Int, error)
+}
+
 type distributionQueryClient interface {
 	GetParams(ctx context.Context) (distribution.ParametersResponse, error)
 }
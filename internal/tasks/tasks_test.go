@@ -0,0 +1,137 @@
+package tasks
+
+import (
+	"testing"
+
+	cudoMintTypes "github.com/CudoVentures/cudos-node/x/cudoMint/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// calculateMintedTokensSinceHeightPerBlock is the original per block calculation, every block's amount
+// being truncated to whole acudos before it is added up
+func calculateMintedTokensSinceHeightPerBlock(mintParams cudoMintTypes.GenesisState, genesisInitialHeight, sinceBlock int64, periodDays float64, realBlocksPerDay sdk.Int) sdk.Int {
+	minter := mintParams.Minter
+	params := mintParams.Params
+
+	if minter.NormTimePassed.GT(finalNormTimePassed) {
+		return sdk.NewInt(0)
+	}
+
+	for height := genesisInitialHeight; height < sinceBlock; height++ {
+		minter.NormTimePassed = minter.NormTimePassed.Add(normalizeBlockHeightInc(params.BlocksPerDay))
+	}
+
+	mintAmountInt := sdk.NewInt(0)
+	totalBlocks := int64(float64(realBlocksPerDay.Int64()) * periodDays)
+
+	for height := int64(1); height <= totalBlocks; height++ {
+		if minter.NormTimePassed.GT(finalNormTimePassed) {
+			break
+		}
+
+		incr := normalizeBlockHeightInc(params.BlocksPerDay)
+		mintAmountDec := calculateMintedCoins(minter.NormTimePassed, minter.NormTimePassed.Add(incr))
+		mintAmountInt = mintAmountInt.Add(mintAmountDec.TruncateInt())
+		minter.NormTimePassed = minter.NormTimePassed.Add(incr)
+	}
+
+	return mintAmountInt
+}
+
+func TestCalculateMintedTokensSinceHeight(t *testing.T) {
+	tests := []struct {
+		name             string
+		normTimePassed   string
+		blocksPerDay     string
+		genesisHeight    int64
+		sinceBlock       int64
+		periodDays       float64
+		realBlocksPerDay int64
+	}{
+		{name: "start of minting", normTimePassed: "0", blocksPerDay: "17280", genesisHeight: 1, sinceBlock: 1, periodDays: 1, realBlocksPerDay: 13824},
+		{name: "inflation genesis", normTimePassed: "0.53172694105988", blocksPerDay: "17280", genesisHeight: 1, sinceBlock: 20000, periodDays: 2, realBlocksPerDay: 13824},
+		{name: "apr genesis", normTimePassed: "1.06390993412731", blocksPerDay: "13824", genesisHeight: 2704699, sinceBlock: 2714699, periodDays: 1.5, realBlocksPerDay: 13824},
+		{name: "since before genesis", normTimePassed: "5", blocksPerDay: "17280", genesisHeight: 1000, sinceBlock: 10, periodDays: 0.5, realBlocksPerDay: 17280},
+		{name: "crossing the end of minting", normTimePassed: "9.999", blocksPerDay: "17280", genesisHeight: 1, sinceBlock: 1000, periodDays: 1, realBlocksPerDay: 13824},
+		{name: "since past the end of minting", normTimePassed: "9.9999", blocksPerDay: "17280", genesisHeight: 1, sinceBlock: 5000, periodDays: 1, realBlocksPerDay: 13824},
+		{name: "genesis past the end of minting", normTimePassed: "10.5", blocksPerDay: "17280", genesisHeight: 1, sinceBlock: 100, periodDays: 1, realBlocksPerDay: 13824},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			genesisState, err := createGenesisState(tt.normTimePassed, tt.blocksPerDay)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			realBlocksPerDay := sdk.NewInt(tt.realBlocksPerDay)
+
+			minted, err := calculateMintedTokensSinceHeight(*genesisState, tt.genesisHeight, tt.sinceBlock, tt.periodDays, realBlocksPerDay)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := calculateMintedTokensSinceHeightPerBlock(*genesisState, tt.genesisHeight, tt.sinceBlock, tt.periodDays, realBlocksPerDay)
+
+			// Only the per block truncation is lost, which is less than one acudos per block
+			totalBlocks := int64(float64(tt.realBlocksPerDay) * tt.periodDays)
+			diff := minted.Sub(want)
+			if diff.IsNegative() || diff.GT(sdk.NewInt(totalBlocks)) {
+				t.Errorf("minted = %s, per block = %s, difference %s is outside [0, %d]", minted, want, diff, totalBlocks)
+			}
+		})
+	}
+}
+
+func benchmarkMintedTokens(b *testing.B, calculate func(cudoMintTypes.GenesisState, int64, int64, float64, sdk.Int)) {
+	genesisState, err := createGenesisState("0.53172694105988", "17280")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		calculate(*genesisState, 1, 100000, 1, sdk.NewInt(13824))
+	}
+}
+
+func BenchmarkCalculateMintedTokensSinceHeight(b *testing.B) {
+	benchmarkMintedTokens(b, func(mintParams cudoMintTypes.GenesisState, genesisHeight, sinceBlock int64, periodDays float64, realBlocksPerDay sdk.Int) {
+		if _, err := calculateMintedTokensSinceHeight(mintParams, genesisHeight, sinceBlock, periodDays, realBlocksPerDay); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+func BenchmarkCalculateMintedTokensSinceHeightPerBlock(b *testing.B) {
+	benchmarkMintedTokens(b, func(mintParams cudoMintTypes.GenesisState, genesisHeight, sinceBlock int64, periodDays float64, realBlocksPerDay sdk.Int) {
+		calculateMintedTokensSinceHeightPerBlock(mintParams, genesisHeight, sinceBlock, periodDays, realBlocksPerDay)
+	})
+}
+
+func TestGetBlocksPerYear(t *testing.T) {
+	tests := []struct {
+		blocksPerDay string
+		want         uint64
+		wantErr      bool
+	}{
+		{blocksPerDay: "17280", want: 6311520},
+		{blocksPerDay: "13824", want: 5049216},
+		{blocksPerDay: "1", want: 365},
+		{blocksPerDay: "-1", wantErr: true},
+		{blocksPerDay: "many", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.blocksPerDay, func(t *testing.T) {
+			got, err := GetBlocksPerYear(tt.blocksPerDay)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %t", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("blocks per year = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
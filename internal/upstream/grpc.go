@@ -0,0 +1,69 @@
+package upstream
+
+import (
+	"context"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// NewGRPCDialOptions applies the upstream config to gRPC connections to the Cudos nodes: the TLS settings unless
+// the connections are insecure, the user agent, the cudos headers as metadata and the timeout of every call
+func NewGRPCDialOptions(cfg config.Config, insecure bool) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+
+	if insecure {
+		opts = append(opts, grpc.WithInsecure())
+	} else {
+		tlsConfig, err := newTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+
+	if cfg.Upstream.UserAgent != "" {
+		opts = append(opts, grpc.WithUserAgent(cfg.Upstream.UserAgent))
+	}
+
+	headers := make([]string, 0, 2*len(cfg.Upstream.CudosHeaders))
+	for key, value := range cfg.Upstream.CudosHeaders {
+		headers = append(headers, key, value)
+	}
+
+	opts = append(opts,
+		grpc.WithChainUnaryInterceptor(newUnaryInterceptor(cfg, headers)),
+		grpc.WithChainStreamInterceptor(newStreamInterceptor(headers)),
+	)
+
+	return opts, nil
+}
+
+func newUnaryInterceptor(cfg config.Config, headers []string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if cfg.Upstream.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, cfg.Upstream.Timeout)
+			defer cancel()
+		}
+
+		if len(headers) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, headers...)
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// Streams live as long as their context, so only the headers are added to them
+func newStreamInterceptor(headers []string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if len(headers) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, headers...)
+		}
+
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,68 @@
+package upstream
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestGRPCDialOptions(t *testing.T) {
+	var gotMetadata metadata.MD
+	var gotDeadline time.Time
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		gotMetadata, _ = metadata.FromIncomingContext(ctx)
+		gotDeadline, _ = ctx.Deadline()
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	go server.Serve(listener)
+	defer server.Stop()
+
+	var cfg config.Config
+	cfg.Upstream.Timeout = time.Minute
+	cfg.Upstream.UserAgent = "stats-test"
+	cfg.Upstream.CudosHeaders = map[string]string{"X-Api-Key": "secret"}
+
+	opts, err := NewGRPCDialOptions(cfg, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+
+	conn, err := grpc.Dial("bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := gotMetadata.Get("x-api-key"); len(got) != 1 || got[0] != "secret" {
+		t.Errorf("x-api-key = %v, want [secret]", got)
+	}
+
+	if got := gotMetadata.Get("user-agent"); len(got) != 1 || len(got[0]) < len("stats-test") || got[0][:len("stats-test")] != "stats-test" {
+		t.Errorf("user-agent = %v, want it to start with stats-test", got)
+	}
+
+	if gotDeadline.IsZero() || gotDeadline.Before(start) || gotDeadline.After(start.Add(time.Minute+time.Second)) {
+		t.Errorf("deadline = %s, want about a minute from %s", gotDeadline, start)
+	}
+}
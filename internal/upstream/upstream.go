@@ -0,0 +1,92 @@
+package upstream
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/CudoVentures/cudos-stats-v2-service/internal/config"
+)
+
+// NewTransport creates the transport whose connections are shared by the clients of all upstream HTTP APIs.
+// Unset limits keep the defaults of Go's default transport.
+func NewTransport(cfg config.Config) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.Upstream.MaxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.Upstream.MaxIdleConns
+	}
+
+	if cfg.Upstream.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.Upstream.MaxIdleConnsPerHost
+	}
+
+	if cfg.Upstream.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.Upstream.IdleConnTimeout
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	return transport, nil
+}
+
+// The TLS config is shared by the HTTP and gRPC connections to the nodes
+func newTLSConfig(cfg config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.Upstream.TLS.InsecureSkipVerify}
+
+	if cfg.Upstream.TLS.CAFile != "" {
+		pem, err := ioutil.ReadFile(cfg.Upstream.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file %s: %s", cfg.Upstream.TLS.CAFile, err)
+		}
+
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, errors.New("ca file has no certificates")
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	return tlsConfig, nil
+}
+
+// NewClient creates a client that sends the configured user agent and headers with every request over transport
+func NewClient(cfg config.Config, transport http.RoundTripper, headers map[string]string) *http.Client {
+	return &http.Client{
+		Timeout: cfg.Upstream.Timeout,
+		Transport: &headerTransport{
+			next:      transport,
+			userAgent: cfg.Upstream.UserAgent,
+			headers:   headers,
+		},
+	}
+}
+
+type headerTransport struct {
+	next      http.RoundTripper
+	userAgent string
+	headers   map[string]string
+}
+
+// Round trippers must not modify the request they are given, so the headers are set on a copy
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+
+	return t.next.RoundTrip(req)
+}